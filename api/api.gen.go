@@ -13,10 +13,51 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/oapi-codegen/runtime"
 	strictecho "github.com/oapi-codegen/runtime/strictmiddleware/echo"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	ApiKeyAuthScopes = "ApiKeyAuth.Scopes"
+)
+
+// Defines values for EventStatus.
+const (
+	EventStatusDone       EventStatus = "done"
+	EventStatusFailed     EventStatus = "failed"
+	EventStatusProcessing EventStatus = "processing"
+	EventStatusReceived   EventStatus = "received"
+)
+
+// Defines values for EventAttemptOutcome.
+const (
+	EventAttemptOutcomeFailed    EventAttemptOutcome = "failed"
+	EventAttemptOutcomeRetrying  EventAttemptOutcome = "retrying"
+	EventAttemptOutcomeSucceeded EventAttemptOutcome = "succeeded"
+)
+
+// Defines values for EventAttemptReason.
+const (
+	Error   EventAttemptReason = "error"
+	Panic   EventAttemptReason = "panic"
+	Timeout EventAttemptReason = "timeout"
+)
+
+// Defines values for WorkerState.
+const (
+	Dead    WorkerState = "dead"
+	Live    WorkerState = "live"
+	Stopped WorkerState = "stopped"
 )
 
 // ErrorBadRequest defines model for ErrorBadRequest.
 type ErrorBadRequest struct {
+	Code    int           `json:"code"`
+	Errors  *[]FieldError `json:"errors,omitempty"`
+	Message string        `json:"message"`
+}
+
+// ErrorForbidden defines model for ErrorForbidden.
+type ErrorForbidden struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
@@ -27,6 +68,115 @@ type ErrorInternal struct {
 	Message string `json:"message"`
 }
 
+// ErrorNotFound defines model for ErrorNotFound.
+type ErrorNotFound struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorServiceUnavailable defines model for ErrorServiceUnavailable.
+type ErrorServiceUnavailable struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorTooManyRequests defines model for ErrorTooManyRequests.
+type ErrorTooManyRequests struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorUnauthorized defines model for ErrorUnauthorized.
+type ErrorUnauthorized struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Event defines model for Event.
+type Event struct {
+	Attempts      int            `json:"attempts"`
+	EventId       string         `json:"event_id"`
+	History       []EventAttempt `json:"history"`
+	LastError     *string        `json:"last_error,omitempty"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+
+	// Paused True when the event is waiting to be processed but processing of its type is paused
+	Paused      bool                   `json:"paused"`
+	Payload     map[string]interface{} `json:"payload"`
+	ProcessedAt *time.Time             `json:"processed_at,omitempty"`
+	ReceivedAt  time.Time              `json:"received_at"`
+	Status      EventStatus            `json:"status"`
+
+	// TenantId Tenant of the API key the event was delivered with
+	TenantId  string    `json:"tenant_id"`
+	Type      *string   `json:"type,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EventStatus defines model for Event.Status.
+type EventStatus string
+
+// EventAttempt defines model for EventAttempt.
+type EventAttempt struct {
+	Attempt int `json:"attempt"`
+
+	// DurationMs Time spent in the event handler, excluding status bookkeeping
+	DurationMs int64               `json:"duration_ms"`
+	Error      *string             `json:"error,omitempty"`
+	FinishedAt time.Time           `json:"finished_at"`
+	Host       string              `json:"host"`
+	Outcome    EventAttemptOutcome `json:"outcome"`
+
+	// Reason Why the attempt failed; absent for successful attempts
+	Reason    *EventAttemptReason `json:"reason,omitempty"`
+	StartedAt time.Time           `json:"started_at"`
+	WorkerId  string              `json:"worker_id"`
+}
+
+// EventAttemptOutcome defines model for EventAttempt.Outcome.
+type EventAttemptOutcome string
+
+// EventAttemptReason Why the attempt failed; absent for successful attempts
+type EventAttemptReason string
+
+// FieldError defines model for FieldError.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProcessingEvent defines model for ProcessingEvent.
+type ProcessingEvent struct {
+	Attempts  int        `json:"attempts"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+	EventId   string     `json:"event_id"`
+	TenantId  string     `json:"tenant_id"`
+	Type      *string    `json:"type,omitempty"`
+}
+
+// QueueControl defines model for QueueControl.
+type QueueControl struct {
+	Paused bool    `json:"paused"`
+	Reason *string `json:"reason,omitempty"`
+
+	// Scope Event type, or `*` for every type
+	Scope     string    `json:"scope"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// QueueControlList defines model for QueueControlList.
+type QueueControlList struct {
+	Controls []QueueControl `json:"controls"`
+}
+
+// QueueControlRequest defines model for QueueControlRequest.
+type QueueControlRequest struct {
+	Reason *string `json:"reason,omitempty"`
+
+	// Type Event type to pause or resume. Omit to act on every type.
+	Type *string `json:"type,omitempty"`
+}
+
 // WebhookAckResponse defines model for WebhookAckResponse.
 type WebhookAckResponse struct {
 	Ok bool `json:"ok"`
@@ -41,16 +191,72 @@ type WebhookPaymentRequest struct {
 	Type       string    `json:"type"`
 }
 
+// Worker defines model for Worker.
+type Worker struct {
+	// Concurrency Configured pool size as of the last heartbeat
+	Concurrency int                `json:"concurrency"`
+	HeartbeatAt time.Time          `json:"heartbeat_at"`
+	Host        string             `json:"host"`
+	Id          openapi_types.UUID `json:"id"`
+	Pid         int                `json:"pid"`
+	Processing  []ProcessingEvent  `json:"processing"`
+	StartedAt   time.Time          `json:"started_at"`
+
+	// State `dead` means the heartbeat is older than the configured timeout without a clean shutdown
+	State     WorkerState `json:"state"`
+	StoppedAt *time.Time  `json:"stopped_at,omitempty"`
+	Version   string      `json:"version"`
+}
+
+// WorkerState `dead` means the heartbeat is older than the configured timeout without a clean shutdown
+type WorkerState string
+
+// WorkerList defines model for WorkerList.
+type WorkerList struct {
+	Workers []Worker `json:"workers"`
+}
+
+// Forbidden defines model for Forbidden.
+type Forbidden = ErrorForbidden
+
+// Unauthorized defines model for Unauthorized.
+type Unauthorized = ErrorUnauthorized
+
 // WebhookPaymentParams defines parameters for WebhookPayment.
 type WebhookPaymentParams struct {
+	// XWebhookSignature Hex-encoded HMAC-SHA256 of the raw request body. Required when the server has a signing secret configured.
 	XWebhookSignature *string `json:"X-Webhook-Signature,omitempty"`
+
+	// XWebhookProvider Name of the sending provider. Used as the rate limit key when the server limits per provider.
+	XWebhookProvider *string `json:"X-Webhook-Provider,omitempty"`
 }
 
+// PauseQueueJSONRequestBody defines body for PauseQueue for application/json ContentType.
+type PauseQueueJSONRequestBody = QueueControlRequest
+
+// ResumeQueueJSONRequestBody defines body for ResumeQueue for application/json ContentType.
+type ResumeQueueJSONRequestBody = QueueControlRequest
+
 // WebhookPaymentJSONRequestBody defines body for WebhookPayment for application/json ContentType.
 type WebhookPaymentJSONRequestBody = WebhookPaymentRequest
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// List queue pause controls
+	// (GET /admin/queue)
+	GetQueueControls(ctx echo.Context) error
+	// Pause processing globally or for one event type
+	// (POST /admin/queue/pause)
+	PauseQueue(ctx echo.Context) error
+	// Resume processing globally or for one event type
+	// (POST /admin/queue/resume)
+	ResumeQueue(ctx echo.Context) error
+	// List worker pool processes and the events each is processing
+	// (GET /admin/workers)
+	ListWorkers(ctx echo.Context) error
+	// Get a webhook event with its attempt history
+	// (GET /events/{event_id})
+	GetEvent(ctx echo.Context, eventId string) error
 	// Payment webhook
 	// (POST /webhooks/payments)
 	WebhookPayment(ctx echo.Context, params WebhookPaymentParams) error
@@ -61,10 +267,74 @@ type ServerInterfaceWrapper struct {
 	Handler ServerInterface
 }
 
+// GetQueueControls converts echo context to params.
+func (w *ServerInterfaceWrapper) GetQueueControls(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetQueueControls(ctx)
+	return err
+}
+
+// PauseQueue converts echo context to params.
+func (w *ServerInterfaceWrapper) PauseQueue(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PauseQueue(ctx)
+	return err
+}
+
+// ResumeQueue converts echo context to params.
+func (w *ServerInterfaceWrapper) ResumeQueue(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ResumeQueue(ctx)
+	return err
+}
+
+// ListWorkers converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWorkers(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(ApiKeyAuthScopes, []string{"admin"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWorkers(ctx)
+	return err
+}
+
+// GetEvent converts echo context to params.
+func (w *ServerInterfaceWrapper) GetEvent(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "event_id" -------------
+	var eventId string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "event_id", ctx.Param("event_id"), &eventId, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter event_id: %s", err))
+	}
+
+	ctx.Set(ApiKeyAuthScopes, []string{"read"})
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetEvent(ctx, eventId)
+	return err
+}
+
 // WebhookPayment converts echo context to params.
 func (w *ServerInterfaceWrapper) WebhookPayment(ctx echo.Context) error {
 	var err error
 
+	ctx.Set(ApiKeyAuthScopes, []string{"ingest"})
+
 	// Parameter object where we will unmarshal all parameters from the context
 	var params WebhookPaymentParams
 
@@ -84,6 +354,21 @@ func (w *ServerInterfaceWrapper) WebhookPayment(ctx echo.Context) error {
 
 		params.XWebhookSignature = &XWebhookSignature
 	}
+	// ------------- Optional header parameter "X-Webhook-Provider" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Webhook-Provider")]; found {
+		var XWebhookProvider string
+		n := len(valueList)
+		if n != 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Expected one value for X-Webhook-Provider, got %d", n))
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Webhook-Provider", valueList[0], &XWebhookProvider, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter X-Webhook-Provider: %s", err))
+		}
+
+		params.XWebhookProvider = &XWebhookProvider
+	}
 
 	// Invoke the callback with all the unmarshaled arguments
 	err = w.Handler.WebhookPayment(ctx, params)
@@ -118,10 +403,264 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 		Handler: si,
 	}
 
+	router.GET(baseURL+"/admin/queue", wrapper.GetQueueControls)
+	router.POST(baseURL+"/admin/queue/pause", wrapper.PauseQueue)
+	router.POST(baseURL+"/admin/queue/resume", wrapper.ResumeQueue)
+	router.GET(baseURL+"/admin/workers", wrapper.ListWorkers)
+	router.GET(baseURL+"/events/:event_id", wrapper.GetEvent)
 	router.POST(baseURL+"/webhooks/payments", wrapper.WebhookPayment)
 
 }
 
+type ForbiddenJSONResponse ErrorForbidden
+
+type UnauthorizedJSONResponse ErrorUnauthorized
+
+type GetQueueControlsRequestObject struct {
+}
+
+type GetQueueControlsResponseObject interface {
+	VisitGetQueueControlsResponse(w http.ResponseWriter) error
+}
+
+type GetQueueControls200JSONResponse QueueControlList
+
+func (response GetQueueControls200JSONResponse) VisitGetQueueControlsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQueueControls401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response GetQueueControls401JSONResponse) VisitGetQueueControlsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQueueControls403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response GetQueueControls403JSONResponse) VisitGetQueueControlsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetQueueControls500JSONResponse ErrorInternal
+
+func (response GetQueueControls500JSONResponse) VisitGetQueueControlsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PauseQueueRequestObject struct {
+	Body *PauseQueueJSONRequestBody
+}
+
+type PauseQueueResponseObject interface {
+	VisitPauseQueueResponse(w http.ResponseWriter) error
+}
+
+type PauseQueue200JSONResponse QueueControl
+
+func (response PauseQueue200JSONResponse) VisitPauseQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PauseQueue400JSONResponse ErrorBadRequest
+
+func (response PauseQueue400JSONResponse) VisitPauseQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PauseQueue401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response PauseQueue401JSONResponse) VisitPauseQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PauseQueue403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response PauseQueue403JSONResponse) VisitPauseQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PauseQueue500JSONResponse ErrorInternal
+
+func (response PauseQueue500JSONResponse) VisitPauseQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResumeQueueRequestObject struct {
+	Body *ResumeQueueJSONRequestBody
+}
+
+type ResumeQueueResponseObject interface {
+	VisitResumeQueueResponse(w http.ResponseWriter) error
+}
+
+type ResumeQueue200JSONResponse QueueControl
+
+func (response ResumeQueue200JSONResponse) VisitResumeQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResumeQueue400JSONResponse ErrorBadRequest
+
+func (response ResumeQueue400JSONResponse) VisitResumeQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResumeQueue401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response ResumeQueue401JSONResponse) VisitResumeQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResumeQueue403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response ResumeQueue403JSONResponse) VisitResumeQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ResumeQueue500JSONResponse ErrorInternal
+
+func (response ResumeQueue500JSONResponse) VisitResumeQueueResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkersRequestObject struct {
+}
+
+type ListWorkersResponseObject interface {
+	VisitListWorkersResponse(w http.ResponseWriter) error
+}
+
+type ListWorkers200JSONResponse WorkerList
+
+func (response ListWorkers200JSONResponse) VisitListWorkersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkers401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response ListWorkers401JSONResponse) VisitListWorkersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkers403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response ListWorkers403JSONResponse) VisitListWorkersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkers500JSONResponse ErrorInternal
+
+func (response ListWorkers500JSONResponse) VisitListWorkersResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetEventRequestObject struct {
+	EventId string `json:"event_id"`
+}
+
+type GetEventResponseObject interface {
+	VisitGetEventResponse(w http.ResponseWriter) error
+}
+
+type GetEvent200JSONResponse Event
+
+func (response GetEvent200JSONResponse) VisitGetEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetEvent401JSONResponse struct{ UnauthorizedJSONResponse }
+
+func (response GetEvent401JSONResponse) VisitGetEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetEvent403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response GetEvent403JSONResponse) VisitGetEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetEvent404JSONResponse ErrorNotFound
+
+func (response GetEvent404JSONResponse) VisitGetEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetEvent500JSONResponse ErrorInternal
+
+func (response GetEvent500JSONResponse) VisitGetEventResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
 type WebhookPaymentRequestObject struct {
 	Params WebhookPaymentParams
 	Body   *WebhookPaymentJSONRequestBody
@@ -149,6 +688,50 @@ func (response WebhookPayment400JSONResponse) VisitWebhookPaymentResponse(w http
 	return json.NewEncoder(w).Encode(response)
 }
 
+type WebhookPayment401JSONResponse ErrorUnauthorized
+
+func (response WebhookPayment401JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(401)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WebhookPayment403JSONResponse struct{ ForbiddenJSONResponse }
+
+func (response WebhookPayment403JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WebhookPayment413JSONResponse ErrorBadRequest
+
+func (response WebhookPayment413JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(413)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type WebhookPayment429ResponseHeaders struct {
+	RetryAfter int
+}
+
+type WebhookPayment429JSONResponse struct {
+	Body    ErrorTooManyRequests
+	Headers WebhookPayment429ResponseHeaders
+}
+
+func (response WebhookPayment429JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
 type WebhookPayment500JSONResponse ErrorInternal
 
 func (response WebhookPayment500JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
@@ -158,8 +741,40 @@ func (response WebhookPayment500JSONResponse) VisitWebhookPaymentResponse(w http
 	return json.NewEncoder(w).Encode(response)
 }
 
+type WebhookPayment503ResponseHeaders struct {
+	RetryAfter int
+}
+
+type WebhookPayment503JSONResponse struct {
+	Body    ErrorServiceUnavailable
+	Headers WebhookPayment503ResponseHeaders
+}
+
+func (response WebhookPayment503JSONResponse) VisitWebhookPaymentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", fmt.Sprint(response.Headers.RetryAfter))
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
 // StrictServerInterface represents all server handlers.
 type StrictServerInterface interface {
+	// List queue pause controls
+	// (GET /admin/queue)
+	GetQueueControls(ctx context.Context, request GetQueueControlsRequestObject) (GetQueueControlsResponseObject, error)
+	// Pause processing globally or for one event type
+	// (POST /admin/queue/pause)
+	PauseQueue(ctx context.Context, request PauseQueueRequestObject) (PauseQueueResponseObject, error)
+	// Resume processing globally or for one event type
+	// (POST /admin/queue/resume)
+	ResumeQueue(ctx context.Context, request ResumeQueueRequestObject) (ResumeQueueResponseObject, error)
+	// List worker pool processes and the events each is processing
+	// (GET /admin/workers)
+	ListWorkers(ctx context.Context, request ListWorkersRequestObject) (ListWorkersResponseObject, error)
+	// Get a webhook event with its attempt history
+	// (GET /events/{event_id})
+	GetEvent(ctx context.Context, request GetEventRequestObject) (GetEventResponseObject, error)
 	// Payment webhook
 	// (POST /webhooks/payments)
 	WebhookPayment(ctx context.Context, request WebhookPaymentRequestObject) (WebhookPaymentResponseObject, error)
@@ -177,6 +792,135 @@ type strictHandler struct {
 	middlewares []StrictMiddlewareFunc
 }
 
+// GetQueueControls operation middleware
+func (sh *strictHandler) GetQueueControls(ctx echo.Context) error {
+	var request GetQueueControlsRequestObject
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetQueueControls(ctx.Request().Context(), request.(GetQueueControlsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetQueueControls")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(GetQueueControlsResponseObject); ok {
+		return validResponse.VisitGetQueueControlsResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// PauseQueue operation middleware
+func (sh *strictHandler) PauseQueue(ctx echo.Context) error {
+	var request PauseQueueRequestObject
+
+	var body PauseQueueJSONRequestBody
+	if err := ctx.Bind(&body); err != nil {
+		return err
+	}
+	request.Body = &body
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.PauseQueue(ctx.Request().Context(), request.(PauseQueueRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PauseQueue")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(PauseQueueResponseObject); ok {
+		return validResponse.VisitPauseQueueResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// ResumeQueue operation middleware
+func (sh *strictHandler) ResumeQueue(ctx echo.Context) error {
+	var request ResumeQueueRequestObject
+
+	var body ResumeQueueJSONRequestBody
+	if err := ctx.Bind(&body); err != nil {
+		return err
+	}
+	request.Body = &body
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.ResumeQueue(ctx.Request().Context(), request.(ResumeQueueRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ResumeQueue")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(ResumeQueueResponseObject); ok {
+		return validResponse.VisitResumeQueueResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// ListWorkers operation middleware
+func (sh *strictHandler) ListWorkers(ctx echo.Context) error {
+	var request ListWorkersRequestObject
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.ListWorkers(ctx.Request().Context(), request.(ListWorkersRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListWorkers")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(ListWorkersResponseObject); ok {
+		return validResponse.VisitListWorkersResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// GetEvent operation middleware
+func (sh *strictHandler) GetEvent(ctx echo.Context, eventId string) error {
+	var request GetEventRequestObject
+
+	request.EventId = eventId
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.GetEvent(ctx.Request().Context(), request.(GetEventRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetEvent")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(GetEventResponseObject); ok {
+		return validResponse.VisitGetEventResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
 // WebhookPayment operation middleware
 func (sh *strictHandler) WebhookPayment(ctx echo.Context, params WebhookPaymentParams) error {
 	var request WebhookPaymentRequestObject
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	"worker-pool/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func runAPIKey(args []string) error {
+	if len(args) < 1 {
+		return errors.New("apikey: missing subcommand (create, revoke, list)")
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("apikey "+sub, flag.ExitOnError)
+	configPath := configFlag(fs)
+	name := fs.String("name", "", "what the key is for, shown in apikey list")
+	tenant := fs.String("tenant", "", "tenant the key acts for, or * for an operator key")
+	scopes := fs.String("scopes", services.ScopeIngest, "comma-separated scopes: ingest, read, or admin for an operator key")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg.Database.StatsInterval = 0
+	cfg.Database.AutoMigrate = false
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	store, err := db.InitPostgres(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	keys := services.NewAPIKeyService(store)
+
+	switch sub {
+	case "create":
+		plaintext, key, err := keys.Create(ctx, *name, *tenant, strings.Split(*scopes, ","))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created key %s (%s) for tenant %s with scopes %s.\n", key.ID, key.Prefix, key.TenantID, strings.Join(key.Scopes, ","))
+		fmt.Println("Store it now; it cannot be shown again:")
+		fmt.Println(plaintext)
+		return nil
+	case "revoke":
+		if fs.NArg() != 1 {
+			return errors.New("apikey revoke: expected the key id")
+		}
+		id, err := uuid.Parse(fs.Arg(0))
+		if err != nil {
+			return fmt.Errorf("apikey revoke: invalid key id: %w", err)
+		}
+		key, err := keys.Revoke(ctx, id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked key %s (%s).\n", key.ID, key.Prefix)
+		return nil
+	case "list":
+		return listAPIKeys(ctx, keys)
+	default:
+		return fmt.Errorf("apikey: unknown subcommand %q", sub)
+	}
+}
+
+func listAPIKeys(ctx context.Context, keys *services.APIKeyService) error {
+	list, err := keys.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No API keys.")
+		return nil
+	}
+
+	const layout = "2006-01-02 15:04:05Z07:00"
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPREFIX\tNAME\tTENANT\tSCOPES\tLAST USED\tSTATE")
+	for _, k := range list {
+		lastUsed := "never"
+		if k.LastUsedAt.Valid {
+			lastUsed = k.LastUsedAt.Time.Format(layout)
+		}
+		state := "active"
+		if k.RevokedAt.Valid {
+			state = "revoked " + k.RevokedAt.Time.Format(layout)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Name, k.TenantID, strings.Join(k.Scopes, ","), lastUsed, state)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,64 @@
+// Command admin runs operational tasks against the worker pool database.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const usage = `usage: admin <command> [flags] [args]
+
+commands:
+  migrate up                 apply all pending migrations
+  migrate down <n>           roll back the last n migrations
+  migrate version            show the applied and embedded versions
+  migrate force <version>    mark version as applied and clear the dirty flag
+  queue status               show pause controls
+  queue pause [--type T] [--reason R]
+                             stop workers claiming events of type T (all types without --type)
+  queue resume [--type T]    resume processing paused by "queue pause"
+  apikey create --name N --tenant T [--scopes ingest,read,admin]
+                             issue an API key and print it once
+  apikey revoke <id>         stop accepting a key
+  apikey list                show keys, their scopes and last use
+
+Every command accepts --config <file> (default $CONFIG_FILE) after the subcommand.
+`
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "queue":
+		err = runQueue(os.Args[2:])
+	case "apikey":
+		err = runAPIKey(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("Command failed")
+	}
+}
+
+func configFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+}
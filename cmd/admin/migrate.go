@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+
+	"github.com/rs/zerolog/log"
+)
+
+func runMigrate(args []string) error {
+	if len(args) < 1 {
+		return errors.New("migrate: missing subcommand (up, down, version, force)")
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("migrate "+sub, flag.ExitOnError)
+	configPath := configFlag(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	migrator, err := db.NewMigrator(cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch rest := fs.Args(); sub {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		steps, err := intArg(rest, "down <n>")
+		if err != nil {
+			return err
+		}
+		if err := migrator.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "force":
+		version, err := intArg(rest, "force <version>")
+		if err != nil {
+			return err
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+	case "version":
+	default:
+		return fmt.Errorf("migrate: unknown subcommand %q", sub)
+	}
+
+	return printVersion(migrator)
+}
+
+func printVersion(migrator *db.Migrator) error {
+	version, dirty, ok, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	expected, err := db.ExpectedVersion()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		log.Info().Uint("expected", expected).Msg("No migrations applied")
+		return nil
+	}
+	log.Info().
+		Uint("version", version).
+		Bool("dirty", dirty).
+		Uint("expected", expected).
+		Msg("Migration version")
+	return nil
+}
+
+func intArg(args []string, usage string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("migrate: usage: migrate %s", usage)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("migrate: %q is not a number", args[0])
+	}
+	return n, nil
+}
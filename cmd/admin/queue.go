@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+)
+
+func runQueue(args []string) error {
+	if len(args) < 1 {
+		return errors.New("queue: missing subcommand (status, pause, resume)")
+	}
+	sub := args[0]
+
+	fs := flag.NewFlagSet("queue "+sub, flag.ExitOnError)
+	configPath := configFlag(fs)
+	eventType := fs.String("type", "", "event type to pause or resume (default: every type)")
+	reason := fs.String("reason", "", "why processing is paused, shown in queue status")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+	// A one-shot command has no use for the periodic pool log or migrations.
+	cfg.Database.StatsInterval = 0
+	cfg.Database.AutoMigrate = false
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	store, err := db.InitPostgres(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	queue := services.NewQueueService(store)
+
+	switch sub {
+	case "pause":
+		var r *string
+		if *reason != "" {
+			r = reason
+		}
+		if _, err := queue.Pause(ctx, *eventType, r); err != nil {
+			return err
+		}
+	case "resume":
+		if _, err := queue.Resume(ctx, *eventType); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("queue: unknown subcommand %q", sub)
+	}
+
+	controls, err := queue.List(ctx)
+	if err != nil {
+		return err
+	}
+	return printControls(controls)
+}
+
+func printControls(controls []sqlc.QueueControl) error {
+	if len(controls) == 0 {
+		fmt.Println("No queue controls set; every type is being processed.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SCOPE\tSTATE\tUPDATED\tREASON")
+	for _, c := range controls {
+		state := "running"
+		if c.Paused {
+			state = "paused"
+		}
+		reason := ""
+		if c.Reason != nil {
+			reason = *c.Reason
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Scope, state, c.UpdatedAt.Time.Format("2006-01-02 15:04:05Z07:00"), reason)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/rs/zerolog/log"
+
+	"worker-pool/api"
+)
+
+// lagPollInterval is how often a sampled event is polled until processed.
+const lagPollInterval = 250 * time.Millisecond
+
+// lagTracker measures end-to-end lag, from sending an event to its
+// processed_at, for a sample of accepted events by polling GET /events/{id}.
+// processed_at is the server's clock, so the lag is only as good as the
+// clocks agree.
+type lagTracker struct {
+	client    *http.Client
+	eventsURL string
+	apiKey    string
+	sample    float64
+	timeout   time.Duration
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	lag     *hdrhistogram.Histogram
+	sampled int64
+	failed  int64
+	pending int64
+}
+
+// newLagTracker returns nil when sample is 0, which tracks nothing.
+func newLagTracker(client *http.Client, target, apiKey string, sample float64, timeout time.Duration) *lagTracker {
+	if sample <= 0 {
+		return nil
+	}
+	return &lagTracker{
+		client:    client,
+		eventsURL: target + "/events/",
+		apiKey:    apiKey,
+		sample:    sample,
+		timeout:   timeout,
+		lag:       newHistogram(),
+	}
+}
+
+// track samples an accepted event and, if chosen, polls it in the
+// background until it is done, failed, timed out or ctx is cancelled.
+func (t *lagTracker) track(ctx context.Context, eventID string, sentAt time.Time) {
+	if t == nil || rand.Float64() >= t.sample {
+		return
+	}
+	t.mu.Lock()
+	t.sampled++
+	t.mu.Unlock()
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.poll(ctx, eventID, sentAt)
+	}()
+}
+
+func (t *lagTracker) poll(ctx context.Context, eventID string, sentAt time.Time) {
+	ctx, cancel := context.WithDeadline(ctx, sentAt.Add(t.timeout))
+	defer cancel()
+
+	ticker := time.NewTicker(lagPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			t.mu.Lock()
+			t.pending++
+			t.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+
+		ev, err := t.get(ctx, eventID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Debug().Err(err).Str("event_id", eventID).Msg("Event poll failed")
+			}
+			continue
+		}
+
+		switch {
+		case ev.Status == api.EventStatusDone && ev.ProcessedAt != nil:
+			t.mu.Lock()
+			_ = t.lag.RecordValue(micros(ev.ProcessedAt.Sub(sentAt)))
+			t.mu.Unlock()
+			return
+		case ev.Status == api.EventStatusFailed:
+			t.mu.Lock()
+			t.failed++
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+// get fetches the event; a 404 is an error, since the ingest that accepted
+// it may not be visible yet.
+func (t *lagTracker) get(ctx context.Context, eventID string) (api.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.eventsURL+url.PathEscape(eventID), nil)
+	if err != nil {
+		return api.Event{}, err
+	}
+	if t.apiKey != "" {
+		req.Header.Set("X-API-Key", t.apiKey)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return api.Event{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return api.Event{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var ev api.Event
+	if err := json.NewDecoder(resp.Body).Decode(&ev); err != nil {
+		return api.Event{}, err
+	}
+	return ev, nil
+}
+
+// wait blocks until every sampled event has been resolved or given up on.
+func (t *lagTracker) wait() {
+	if t != nil {
+		t.wg.Wait()
+	}
+}
+
+// endToEndReport is the end-to-end section of the final JSON report.
+// Pending events timed out or were still polling when the run was
+// interrupted.
+type endToEndReport struct {
+	Sampled   int64         `json:"sampled"`
+	Processed int64         `json:"processed"`
+	Failed    int64         `json:"failed"`
+	Pending   int64         `json:"pending"`
+	LagMs     latencyReport `json:"lag_ms"`
+}
+
+func (t *lagTracker) report() *endToEndReport {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &endToEndReport{
+		Sampled:   t.sampled,
+		Processed: t.lag.TotalCount(),
+		Failed:    t.failed,
+		Pending:   t.pending,
+		LagMs:     latencyOf(t.lag),
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"worker-pool/api"
+)
+
+// recentEvents is how many sent events are kept as candidates for
+// duplicate deliveries.
+const recentEvents = 1000
+
+var currencies = []string{"NGN", "USD", "GBP", "EUR"}
+
+// generator builds request bodies for a phase's type mix and duplicate
+// ratio. It is shared by every in-flight sender.
+type generator struct {
+	secret string
+
+	mu     sync.Mutex
+	types  []string
+	total  int
+	weight map[string]int
+	chaos  map[string]float64
+	recent []sentEvent
+	next   int
+}
+
+type sentEvent struct {
+	id   string
+	body []byte
+}
+
+func newGenerator(secret string) *generator {
+	return &generator{secret: secret}
+}
+
+// setMix switches to a phase's type weights. Recent events are kept so a
+// phase can redeliver events sent by the one before it.
+func (g *generator) setMix(weights map[string]int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.types = g.types[:0]
+	g.total = 0
+	for eventType := range weights {
+		g.types = append(g.types, eventType)
+		g.total += weights[eventType]
+	}
+	slices.Sort(g.types)
+	g.weight = weights
+}
+
+// setChaos switches to a phase's chaos marker ratios.
+func (g *generator) setChaos(ratios map[string]float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.chaos = ratios
+}
+
+// eventID is a fresh event ID, marked to force a chaos fault per the chaos
+// ratios.
+func (g *generator) eventID() string {
+	roll := rand.Float64()
+	for _, fault := range chaosFaults {
+		if roll < g.chaos[fault] {
+			return "evt_chaos-" + fault + "-" + randomHex(12)
+		}
+		roll -= g.chaos[fault]
+	}
+	return "evt_" + randomHex(12)
+}
+
+// body returns the next request body, its event ID, and whether it
+// redelivers an event already sent.
+func (g *generator) body(duplicateRatio float64) ([]byte, string, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.recent) > 0 && rand.Float64() < duplicateRatio {
+		ev := g.recent[rand.IntN(len(g.recent))]
+		return ev.body, ev.id, true, nil
+	}
+
+	id := g.eventID()
+	body, err := json.Marshal(api.WebhookPaymentRequest{
+		EventId:    id,
+		Type:       g.pickType(),
+		Amount:     fmt.Sprintf("%d", 100+rand.IntN(1_000_000)),
+		Currency:   currencies[rand.IntN(len(currencies))],
+		OccurredAt: time.Now().UTC().Add(-time.Duration(rand.IntN(3600)) * time.Second),
+	})
+	if err != nil {
+		return nil, "", false, fmt.Errorf("marshal: %w", err)
+	}
+
+	ev := sentEvent{id: id, body: body}
+	if len(g.recent) < recentEvents {
+		g.recent = append(g.recent, ev)
+	} else {
+		g.recent[g.next] = ev
+		g.next = (g.next + 1) % recentEvents
+	}
+	return body, id, false, nil
+}
+
+func (g *generator) pickType() string {
+	n := rand.IntN(g.total)
+	for _, eventType := range g.types {
+		n -= g.weight[eventType]
+		if n < 0 {
+			return eventType
+		}
+	}
+	return g.types[len(g.types)-1]
+}
+
+// signature is the X-Webhook-Signature for body: the real HMAC when a
+// signing secret is configured, random hex otherwise.
+func (g *generator) signature(body []byte) string {
+	if g.secret == "" {
+		return randomHex(64)
+	}
+	mac := hmac.New(sha256.New, []byte(g.secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) string {
+	const hex = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = hex[rand.IntN(len(hex))]
+	}
+	return string(b)
+}
@@ -1,143 +1,76 @@
+// Command loadsim sends payment webhooks to the API server following a rate
+// profile or a multi-phase scenario file.
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"math/rand/v2"
-	"net/http"
+	"errors"
+	"flag"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
-	"worker-pool/api"
-
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-const (
-	minBurstSize = 500
-	maxBurstSize = 1000
-	minInterval  = 0
-	maxInterval  = 10 * time.Second
-)
-
-var (
-	eventTypes = []string{"payment.completed", "payment.pending", "payment.failed", "payment.refunded"}
-	currencies = []string{"NGN", "USD", "GBP", "EUR"}
-	baseURL    = "http://localhost:3333"
-)
-
 func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
-
-	log.Info().
-		Str("base_url", baseURL).
-		Int("min_burst", minBurstSize).
-		Int("max_burst", maxBurstSize).
-		Str("interval", fmt.Sprintf("%v–%v", minInterval, maxInterval)).
-		Msg("Load simulator started; send SIGINT/SIGTERM to stop")
-
-	for {
-		interval := minInterval + time.Duration(rand.IntN(int(maxInterval-minInterval)))
-		if interval > 0 {
-			log.Debug().Dur("sleep", interval).Msg("Waiting until next burst")
-			select {
-			case <-ctx.Done():
-				log.Info().Msg("Load simulator stopped")
-				return
-			case <-time.After(interval):
-			}
-		}
-
-		n := minBurstSize + rand.IntN(maxBurstSize-minBurstSize+1)
-		sendBurst(ctx, baseURL, n)
-	}
-}
-
-func sendBurst(ctx context.Context, baseURL string, count int) {
-	url := baseURL + "/webhooks/payments"
-	var wg sync.WaitGroup
-	wg.Add(count)
-
-	start := time.Now()
-	for range count {
-		go func() {
-			defer wg.Done()
-			if err := sendOne(ctx, url); err != nil {
-				log.Debug().Err(err).Msg("Webhook request failed")
-			}
-		}()
+	opts, err := parseOptions(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-	wg.Wait()
-	elapsed := time.Since(start)
-
-	log.Info().
-		Int("requests", count).
-		Dur("elapsed", elapsed).
-		Msg("Burst completed")
-}
-
-func sendOne(ctx context.Context, url string) error {
-	payload := randomPaymentRequest()
-	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
+		log.Fatal().Err(err).Msg("Invalid options")
 	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	level, err := zerolog.ParseLevel(opts.logLevel)
 	if err != nil {
-		return fmt.Errorf("new request: %w", err)
+		log.Fatal().Err(err).Msg("Invalid log level")
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Webhook-Signature", randomSignature())
-
-	resp, err := http.DefaultClient.Do(req)
+	zerolog.SetGlobalLevel(level)
+	sc, err := opts.scenario()
 	if err != nil {
-		return err
+		log.Fatal().Err(err).Msg("Invalid scenario")
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
 	log.Info().
-		Int("status", resp.StatusCode).
-		Str("response", string(respBody)).
-		Msg("Webhook response")
+		Str("target", opts.target).
+		Str("scenario", sc.Name).
+		Int("phases", len(sc.Phases)).
+		Bool("signed", opts.secret != "").
+		Msg("Load simulator started; send SIGINT/SIGTERM to stop")
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("status %d", resp.StatusCode)
+	client := newHTTPClient(sc.maxConcurrency(), opts.timeout)
+	s := &sender{
+		client:         client,
+		url:            opts.target + "/webhooks/payments",
+		apiKey:         opts.apiKey,
+		gen:            newGenerator(opts.secret),
+		lag:            newLagTracker(client, opts.target, opts.readKey, opts.lagSample, opts.lagTimeout),
+		reportInterval: opts.reportInterval,
 	}
-	return nil
-}
-
-func randomPaymentRequest() api.WebhookPaymentRequest {
-	amount := fmt.Sprintf("%d", 100+rand.IntN(1_000_000))
-	return api.WebhookPaymentRequest{
-		EventId:    "evt_" + randomHex(12),
-		Type:       eventTypes[rand.IntN(len(eventTypes))],
-		Amount:     amount,
-		Currency:   currencies[rand.IntN(len(currencies))],
-		OccurredAt: time.Now().UTC().Add(-time.Duration(rand.IntN(3600)) * time.Second),
+	var recorders []*recorder
+	for _, ph := range sc.Phases {
+		if ctx.Err() != nil {
+			break
+		}
+		recorders = append(recorders, s.runPhase(ctx, ph))
 	}
-}
 
-func randomSignature() string {
-	return randomHex(32)
-}
+	if s.lag != nil && ctx.Err() == nil {
+		log.Info().Msg("Waiting for sampled events to be processed")
+	}
+	s.lag.wait()
 
-func randomHex(n int) string {
-	const hex = "0123456789abcdef"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = hex[rand.IntN(len(hex))]
+	report := buildRunReport(sc.Name, opts.target, recorders)
+	report.EndToEnd = s.lag.report()
+	if err := writeReport(opts.reportPath, report); err != nil {
+		log.Error().Err(err).Msg("Failed to write report")
 	}
-	return string(b)
+	log.Info().Msg("Load simulator stopped")
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// options are the command line flags. Every flag can also be set through
+// the environment variable named in its usage; the flag wins.
+type options struct {
+	target       string
+	apiKey       string
+	secret       string
+	scenarioPath string
+	duration     time.Duration
+	timeout      time.Duration
+	defaults     phase
+
+	reportPath     string
+	reportInterval time.Duration
+	readKey        string
+	lagSample      float64
+	lagTimeout     time.Duration
+	logLevel       string
+}
+
+func parseOptions(args []string) (options, error) {
+	fs := flag.NewFlagSet("loadsim", flag.ContinueOnError)
+	var o options
+	var types, chaos string
+	var env envReader
+
+	fs.StringVar(&o.target, "url", env.string("API_URL", "http://localhost:3333"), "API server base URL ($API_URL)")
+	fs.StringVar(&o.apiKey, "api-key", env.string("API_KEY", ""), "ingest-scoped key sent as X-API-Key ($API_KEY)")
+	fs.StringVar(&o.secret, "secret", env.string("WEBHOOK_SIGNING_SECRET", ""), "sign bodies with this secret; random signatures when empty ($WEBHOOK_SIGNING_SECRET)")
+	fs.StringVar(&o.scenarioPath, "scenario", env.string("LOADSIM_SCENARIO", ""), "YAML scenario file; flags below fill fields its phases leave out ($LOADSIM_SCENARIO)")
+	fs.DurationVar(&o.duration, "duration", env.duration("LOADSIM_DURATION", 0), "how long to run without a scenario; 0 runs until interrupted ($LOADSIM_DURATION)")
+	fs.StringVar(&o.defaults.Mode, "mode", env.string("LOADSIM_MODE", modeOpen), "open (fixed arrival rate) or closed (each worker waits for its response) ($LOADSIM_MODE)")
+	fs.StringVar(&o.defaults.Profile, "profile", env.string("LOADSIM_PROFILE", profileConstant), "rate profile: constant, ramp, spike or sine ($LOADSIM_PROFILE)")
+	fs.Float64Var(&o.defaults.Rate, "rate", env.float("LOADSIM_RATE", 50), "requests per second; the start, baseline or trough of other profiles ($LOADSIM_RATE)")
+	fs.Float64Var(&o.defaults.Peak, "peak", env.float("LOADSIM_PEAK", 500), "end of a ramp, spike rate or sine crest ($LOADSIM_PEAK)")
+	fs.DurationVar(&o.defaults.Period, "period", env.duration("LOADSIM_PERIOD", time.Minute), "spike interval or sine wavelength ($LOADSIM_PERIOD)")
+	fs.DurationVar(&o.defaults.SpikeDuration, "spike-duration", env.duration("LOADSIM_SPIKE_DURATION", 5*time.Second), "length of each spike ($LOADSIM_SPIKE_DURATION)")
+	fs.IntVar(&o.defaults.Concurrency, "concurrency", env.int("LOADSIM_CONCURRENCY", 100), "sending workers, and so the most requests in flight ($LOADSIM_CONCURRENCY)")
+	fs.DurationVar(&o.timeout, "timeout", env.duration("LOADSIM_TIMEOUT", 30*time.Second), "per-request timeout ($LOADSIM_TIMEOUT)")
+	fs.StringVar(&types, "types", env.string("LOADSIM_TYPES", "payment.completed=1,payment.pending=1,payment.failed=1,payment.refunded=1"), "event type mix as type=weight pairs ($LOADSIM_TYPES)")
+	fs.Float64Var(&o.defaults.DuplicateRatio, "duplicates", env.float("LOADSIM_DUPLICATE_RATIO", 0), "share of requests redelivering an event already sent ($LOADSIM_DUPLICATE_RATIO)")
+
+	fs.StringVar(&chaos, "chaos", env.string("LOADSIM_CHAOS", ""), "share of events marked to force a worker chaos fault, e.g. error=0.05,panic=0.01 ($LOADSIM_CHAOS)")
+	fs.StringVar(&o.reportPath, "report", env.string("LOADSIM_REPORT", ""), "write the final JSON report to this file instead of stdout ($LOADSIM_REPORT)")
+	fs.DurationVar(&o.reportInterval, "report-interval", env.duration("LOADSIM_REPORT_INTERVAL", 10*time.Second), "how often to log progress; 0 disables ($LOADSIM_REPORT_INTERVAL)")
+	fs.Float64Var(&o.lagSample, "lag-sample", env.float("LOADSIM_LAG_SAMPLE", 0), "share of accepted events polled for end-to-end lag; 0 disables ($LOADSIM_LAG_SAMPLE)")
+	fs.DurationVar(&o.lagTimeout, "lag-timeout", env.duration("LOADSIM_LAG_TIMEOUT", time.Minute), "give up on a sampled event not processed within this long ($LOADSIM_LAG_TIMEOUT)")
+	fs.StringVar(&o.logLevel, "log-level", env.string("LOG_LEVEL", "info"), "log level; debug logs every response ($LOG_LEVEL)")
+	fs.StringVar(&o.readKey, "read-key", env.string("API_READ_KEY", ""), "read-scoped key for lag polling; defaults to -api-key ($API_READ_KEY)")
+
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	if err := errors.Join(env.errs...); err != nil {
+		return options{}, err
+	}
+	if o.lagSample < 0 || o.lagSample > 1 {
+		return options{}, fmt.Errorf("lag-sample: must be between 0 and 1")
+	}
+	if o.readKey == "" {
+		o.readKey = o.apiKey
+	}
+
+	mix, err := parseTypeMix(types)
+	if err != nil {
+		return options{}, err
+	}
+	o.defaults.Types = mix
+	if o.defaults.Chaos, err = parseChaosMix(chaos); err != nil {
+		return options{}, err
+	}
+	return o, nil
+}
+
+// scenario is the scenario file with flag defaults applied, or a single
+// phase built from the flags.
+func (o options) scenario() (scenario, error) {
+	s := scenario{Name: "flags", Phases: []phase{{Name: o.defaults.Profile, Duration: o.duration}}}
+	if o.scenarioPath != "" {
+		var err error
+		if s, err = loadScenario(o.scenarioPath); err != nil {
+			return scenario{}, err
+		}
+	}
+
+	for i := range s.Phases {
+		if s.Phases[i].Name == "" {
+			s.Phases[i].Name = fmt.Sprintf("phase-%d", i+1)
+		}
+		s.Phases[i] = s.Phases[i].withDefaults(o.defaults)
+		if err := s.Phases[i].validate(); err != nil {
+			return scenario{}, err
+		}
+	}
+	return s, nil
+}
+
+// parseTypeMix reads "type=weight,..."; a type without a weight counts 1.
+func parseTypeMix(s string) (map[string]int, error) {
+	mix := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		eventType, weight, found := strings.Cut(pair, "=")
+		w := 1
+		if found {
+			var err error
+			if w, err = strconv.Atoi(strings.TrimSpace(weight)); err != nil {
+				return nil, fmt.Errorf("types: invalid weight in %q", pair)
+			}
+		}
+		mix[strings.TrimSpace(eventType)] = w
+	}
+	return mix, nil
+}
+
+// parseChaosMix reads "fault=ratio,...".
+func parseChaosMix(s string) (map[string]float64, error) {
+	mix := map[string]float64{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		fault, ratio, _ := strings.Cut(pair, "=")
+		r, err := strconv.ParseFloat(strings.TrimSpace(ratio), 64)
+		if err != nil {
+			return nil, fmt.Errorf("chaos: invalid ratio in %q", pair)
+		}
+		mix[strings.TrimSpace(fault)] = r
+	}
+	return mix, nil
+}
+
+// envReader reads flag defaults from the environment. A value that does not
+// parse is collected as an error naming the variable rather than silently
+// replaced by the default.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	v, ok := os.LookupEnv(key)
+	return strings.TrimSpace(v), ok && strings.TrimSpace(v) != ""
+}
+
+func (e *envReader) string(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (e *envReader) float(key string, def float64) float64 {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid number %q", key, raw))
+		return def
+	}
+	return v
+}
+
+func (e *envReader) int(key string, def int) int {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid integer %q", key, raw))
+		return def
+	}
+	return v
+}
+
+func (e *envReader) duration(key string, def time.Duration) time.Duration {
+	raw, ok := e.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: invalid duration %q", key, raw))
+		return def
+	}
+	return v
+}
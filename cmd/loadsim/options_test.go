@@ -0,0 +1,189 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets every variable parseOptions reads so the developer's shell
+// cannot leak into a test case.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{
+		"API_URL", "API_KEY", "API_READ_KEY", "WEBHOOK_SIGNING_SECRET", "LOG_LEVEL",
+		"LOADSIM_SCENARIO", "LOADSIM_DURATION", "LOADSIM_MODE", "LOADSIM_PROFILE",
+		"LOADSIM_RATE", "LOADSIM_PEAK", "LOADSIM_PERIOD", "LOADSIM_SPIKE_DURATION",
+		"LOADSIM_CONCURRENCY", "LOADSIM_TIMEOUT", "LOADSIM_TYPES", "LOADSIM_DUPLICATE_RATIO",
+		"LOADSIM_CHAOS", "LOADSIM_REPORT", "LOADSIM_REPORT_INTERVAL",
+		"LOADSIM_LAG_SAMPLE", "LOADSIM_LAG_TIMEOUT",
+	} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string
+		args            []string
+		expectedErrors  []string
+		validateOptions func(*testing.T, options)
+	}{
+		{
+			name: "defaults",
+			validateOptions: func(t *testing.T, o options) {
+				assert.Equal(t, 50.0, o.defaults.Rate)
+				assert.Equal(t, 100, o.defaults.Concurrency)
+				assert.Equal(t, 30*time.Second, o.timeout)
+				assert.Len(t, o.defaults.Types, 4)
+			},
+		},
+		{
+			name: "env values",
+			env: map[string]string{
+				"LOADSIM_RATE":        "120",
+				"LOADSIM_CONCURRENCY": "8",
+				"LOADSIM_TIMEOUT":     "2s",
+				"API_KEY":             "ingest",
+			},
+			validateOptions: func(t *testing.T, o options) {
+				assert.Equal(t, 120.0, o.defaults.Rate)
+				assert.Equal(t, 8, o.defaults.Concurrency)
+				assert.Equal(t, 2*time.Second, o.timeout)
+				assert.Equal(t, "ingest", o.readKey)
+			},
+		},
+		{
+			name: "flags win over env",
+			env:  map[string]string{"LOADSIM_RATE": "120"},
+			args: []string{"-rate", "7"},
+			validateOptions: func(t *testing.T, o options) {
+				assert.Equal(t, 7.0, o.defaults.Rate)
+			},
+		},
+		{
+			name: "invalid env values name the variable",
+			env: map[string]string{
+				"LOADSIM_RATE":        "fast",
+				"LOADSIM_CONCURRENCY": "lots",
+				"LOADSIM_TIMEOUT":     "30",
+			},
+			expectedErrors: []string{
+				`LOADSIM_RATE: invalid number "fast"`,
+				`LOADSIM_CONCURRENCY: invalid integer "lots"`,
+				`LOADSIM_TIMEOUT: invalid duration "30"`,
+			},
+		},
+		{
+			name:           "invalid env value is not hidden by a flag",
+			env:            map[string]string{"LOADSIM_PEAK": "high"},
+			args:           []string{"-peak", "10"},
+			expectedErrors: []string{`LOADSIM_PEAK: invalid number "high"`},
+		},
+		{
+			name:           "invalid type weight",
+			args:           []string{"-types", "payment.completed=x"},
+			expectedErrors: []string{`types: invalid weight in "payment.completed=x"`},
+		},
+		{
+			name:           "invalid chaos ratio",
+			env:            map[string]string{"LOADSIM_CHAOS": "error=often"},
+			expectedErrors: []string{`chaos: invalid ratio in "error=often"`},
+		},
+		{
+			name:           "lag sample out of range",
+			args:           []string{"-lag-sample", "2"},
+			expectedErrors: []string{"lag-sample: must be between 0 and 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			o, err := parseOptions(tt.args)
+			if len(tt.expectedErrors) > 0 {
+				require.Error(t, err)
+				for _, expected := range tt.expectedErrors {
+					assert.Contains(t, err.Error(), expected)
+				}
+				return
+			}
+			require.NoError(t, err)
+			tt.validateOptions(t, o)
+		})
+	}
+}
+
+func TestParseTypeMix(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      map[string]int
+		expectedError string
+	}{
+		{name: "empty", input: "", expected: map[string]int{}},
+		{
+			name:     "weights",
+			input:    "payment.completed=3, payment.pending=1",
+			expected: map[string]int{"payment.completed": 3, "payment.pending": 1},
+		},
+		{name: "missing weight counts one", input: "payment.failed", expected: map[string]int{"payment.failed": 1}},
+		{name: "blank pairs are skipped", input: "payment.failed=2,,", expected: map[string]int{"payment.failed": 2}},
+		// Weights below 1 parse; validate rejects them with the phase name.
+		{name: "zero weight parses", input: "payment.failed=0", expected: map[string]int{"payment.failed": 0}},
+		{name: "non-numeric weight", input: "payment.failed=two", expectedError: `types: invalid weight in "payment.failed=two"`},
+		{name: "fractional weight", input: "payment.failed=1.5", expectedError: `types: invalid weight in "payment.failed=1.5"`},
+		{name: "empty weight", input: "payment.failed=", expectedError: `types: invalid weight in "payment.failed="`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mix, err := parseTypeMix(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mix)
+		})
+	}
+}
+
+func TestParseChaosMix(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      map[string]float64
+		expectedError string
+	}{
+		{name: "empty", input: "", expected: map[string]float64{}},
+		{
+			name:     "ratios",
+			input:    "error=0.05, panic=0.01",
+			expected: map[string]float64{"error": 0.05, "panic": 0.01},
+		},
+		{name: "missing ratio", input: "error", expectedError: `chaos: invalid ratio in "error"`},
+		{name: "non-numeric ratio", input: "error=some", expectedError: `chaos: invalid ratio in "error=some"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mix, err := parseChaosMix(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mix)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/rs/zerolog/log"
+)
+
+// Histograms track microseconds from 1µs to 5 minutes at 3 significant
+// figures.
+const (
+	histogramMin     = 1
+	histogramMax     = int64(5 * time.Minute / time.Microsecond)
+	histogramSigFigs = 3
+)
+
+func newHistogram() *hdrhistogram.Histogram {
+	return hdrhistogram.New(histogramMin, histogramMax, histogramSigFigs)
+}
+
+// recorder collects one phase's results. The interval fields are reset by
+// every progress log; the rest accumulate for the phase.
+type recorder struct {
+	phase string
+
+	mu         sync.Mutex
+	start      time.Time
+	end        time.Time
+	latency    *hdrhistogram.Histogram
+	service    *hdrhistogram.Histogram
+	byStatus   map[int]int64
+	duplicates int64
+	unsent     int64
+
+	interval         *hdrhistogram.Histogram
+	intervalStart    time.Time
+	intervalRequests int64
+	intervalErrors   int64
+}
+
+func newRecorder(phase string) *recorder {
+	now := time.Now()
+	return &recorder{
+		phase:         phase,
+		start:         now,
+		latency:       newHistogram(),
+		service:       newHistogram(),
+		byStatus:      map[int]int64{},
+		interval:      newHistogram(),
+		intervalStart: now,
+	}
+}
+
+// result is one request's outcome. latency is what the sender saw,
+// including any time the request waited for a free worker; service is the
+// HTTP round trip alone. expected is set in a closed loop, where a latency
+// above it stands in for the requests that could not be sent meanwhile.
+// aborted marks a request cut short by interrupting loadsim, whose latency
+// says nothing about the server; requests in flight at the end of a phase
+// run to completion instead.
+type result struct {
+	status    int
+	latency   time.Duration
+	service   time.Duration
+	expected  time.Duration
+	duplicate bool
+	aborted   bool
+}
+
+// record adds one request. Status 0 is a transport error or timeout; it
+// is recorded at the time taken to fail, so an overloaded server shows in
+// the tail rather than vanishing from it. Aborted requests are counted but
+// left out of the histograms.
+func (r *recorder) record(res result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byStatus[res.status]++
+	r.intervalRequests++
+	if res.duplicate {
+		r.duplicates++
+	}
+	if res.status == 0 || res.status >= 400 {
+		r.intervalErrors++
+	}
+	if res.aborted {
+		return
+	}
+	latency, expected := micros(res.latency), int64(0)
+	if res.expected > 0 {
+		expected = micros(res.expected)
+	}
+	_ = r.latency.RecordCorrectedValue(latency, expected)
+	_ = r.interval.RecordCorrectedValue(latency, expected)
+	_ = r.service.RecordValue(micros(res.service))
+}
+
+// addUnsent counts requests the schedule called for but never sent.
+func (r *recorder) addUnsent(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unsent += n
+}
+
+func micros(d time.Duration) int64 {
+	return min(max(d.Microseconds(), histogramMin), histogramMax)
+}
+
+// logProgress logs the requests since the previous call and starts a new
+// interval.
+func (r *recorder) logProgress() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := time.Since(r.intervalStart)
+	log.Info().
+		Str("phase", r.phase).
+		Int64("requests", r.intervalRequests).
+		Float64("rps", round(float64(r.intervalRequests)/elapsed.Seconds())).
+		Int64("errors", r.intervalErrors).
+		Float64("p50_ms", quantileMs(r.interval, 50)).
+		Float64("p95_ms", quantileMs(r.interval, 95)).
+		Float64("p99_ms", quantileMs(r.interval, 99)).
+		Msg("Progress")
+
+	r.interval.Reset()
+	r.intervalStart = time.Now()
+	r.intervalRequests = 0
+	r.intervalErrors = 0
+}
+
+// phaseReport is one phase of the final JSON report.
+type phaseReport struct {
+	Name            string           `json:"name"`
+	DurationSeconds float64          `json:"duration_seconds"`
+	Requests        int64            `json:"requests"`
+	Unsent          int64            `json:"unsent"`
+	Duplicates      int64            `json:"duplicates"`
+	ThroughputRPS   float64          `json:"throughput_rps"`
+	Status          map[string]int64 `json:"status"`
+	LatencyMs       latencyReport    `json:"latency_ms"`
+	ServiceMs       latencyReport    `json:"service_ms"`
+}
+
+type latencyReport struct {
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+func latencyOf(h *hdrhistogram.Histogram) latencyReport {
+	return latencyReport{
+		P50:  quantileMs(h, 50),
+		P95:  quantileMs(h, 95),
+		P99:  quantileMs(h, 99),
+		Max:  round(float64(h.Max()) / 1000),
+		Mean: round(h.Mean() / 1000),
+	}
+}
+
+// finish stops the phase clock used for its throughput.
+func (r *recorder) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.end = time.Now()
+}
+
+func (r *recorder) report() phaseReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := phaseReport{
+		Name:            r.phase,
+		DurationSeconds: round(r.end.Sub(r.start).Seconds()),
+		Unsent:          r.unsent,
+		Duplicates:      r.duplicates,
+		Status:          make(map[string]int64, len(r.byStatus)),
+		LatencyMs:       latencyOf(r.latency),
+		ServiceMs:       latencyOf(r.service),
+	}
+	for status, n := range r.byStatus {
+		out.Status[statusLabel(status)] = n
+		out.Requests += n
+	}
+	if out.DurationSeconds > 0 {
+		out.ThroughputRPS = round(float64(out.Requests) / out.DurationSeconds)
+	}
+	return out
+}
+
+// runReport is the final JSON report: every phase and the run as a whole.
+type runReport struct {
+	Scenario string          `json:"scenario"`
+	Target   string          `json:"target"`
+	Phases   []phaseReport   `json:"phases"`
+	Total    phaseReport     `json:"total"`
+	EndToEnd *endToEndReport `json:"end_to_end,omitempty"`
+}
+
+func buildRunReport(scenarioName, target string, recorders []*recorder) runReport {
+	out := runReport{Scenario: scenarioName, Target: target, Phases: []phaseReport{}}
+	total := phaseReport{Name: "total", Status: map[string]int64{}}
+	latency, service := newHistogram(), newHistogram()
+	for _, r := range recorders {
+		p := r.report()
+		out.Phases = append(out.Phases, p)
+
+		total.DurationSeconds += p.DurationSeconds
+		total.Requests += p.Requests
+		total.Unsent += p.Unsent
+		total.Duplicates += p.Duplicates
+		for status, n := range p.Status {
+			total.Status[status] += n
+		}
+		r.mu.Lock()
+		latency.Merge(r.latency)
+		service.Merge(r.service)
+		r.mu.Unlock()
+	}
+	if total.DurationSeconds > 0 {
+		total.ThroughputRPS = round(float64(total.Requests) / total.DurationSeconds)
+	}
+	total.LatencyMs = latencyOf(latency)
+	total.ServiceMs = latencyOf(service)
+	out.Total = total
+	return out
+}
+
+// writeReport writes r as indented JSON to path, or stdout when path is
+// empty.
+func writeReport(path string, r runReport) error {
+	w := os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func statusLabel(status int) string {
+	if status == 0 {
+		return "error"
+	}
+	return strconv.Itoa(status)
+}
+
+func quantileMs(h *hdrhistogram.Histogram, percentile float64) float64 {
+	return round(float64(h.ValueAtQuantile(percentile)) / 1000)
+}
+
+func round(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
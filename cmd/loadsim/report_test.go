@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Record(t *testing.T) {
+	tests := []struct {
+		name             string
+		results          []result
+		expectedStatus   map[string]int64
+		expectedRecorded int64
+		expectedMaxMs    float64
+	}{
+		{
+			name: "responses",
+			results: []result{
+				{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond},
+				{status: 503, latency: 20 * time.Millisecond, service: 20 * time.Millisecond},
+			},
+			expectedStatus:   map[string]int64{"200": 1, "503": 1},
+			expectedRecorded: 2,
+			expectedMaxMs:    20,
+		},
+		{
+			name: "failed requests are recorded at the time taken to fail",
+			results: []result{
+				{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond},
+				{latency: 40 * time.Millisecond, service: 40 * time.Millisecond},
+			},
+			expectedStatus:   map[string]int64{"200": 1, "error": 1},
+			expectedRecorded: 2,
+			expectedMaxMs:    40,
+		},
+		{
+			name: "timed-out requests raise the tail",
+			results: []result{
+				{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond},
+				{latency: 30 * time.Second, service: 30 * time.Second},
+			},
+			expectedStatus:   map[string]int64{"200": 1, "error": 1},
+			expectedRecorded: 2,
+			expectedMaxMs:    30000,
+		},
+		{
+			name: "aborted requests are counted but not timed",
+			results: []result{
+				{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond},
+				{latency: time.Second, service: time.Second, aborted: true},
+			},
+			expectedStatus:   map[string]int64{"200": 1, "error": 1},
+			expectedRecorded: 1,
+			expectedMaxMs:    10,
+		},
+		{
+			name: "duplicates are counted like any request",
+			results: []result{
+				{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond, duplicate: true},
+			},
+			expectedStatus:   map[string]int64{"200": 1},
+			expectedRecorded: 1,
+			expectedMaxMs:    10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecorder("test")
+			for _, res := range tt.results {
+				rec.record(res)
+			}
+			rec.finish()
+
+			p := rec.report()
+			assert.Equal(t, tt.expectedStatus, p.Status)
+			assert.Equal(t, int64(len(tt.results)), p.Requests)
+			assert.Equal(t, tt.expectedRecorded, rec.latency.TotalCount())
+			assert.Equal(t, tt.expectedRecorded, rec.service.TotalCount())
+			assert.InDelta(t, tt.expectedMaxMs, p.LatencyMs.Max, tt.expectedMaxMs/100)
+			assert.InDelta(t, tt.expectedMaxMs, p.ServiceMs.Max, tt.expectedMaxMs/100)
+		})
+	}
+}
+
+func TestRecorder_RecordCorrectsClosedLoopLatency(t *testing.T) {
+	rec := newRecorder("test")
+	// A 100ms response to a worker due to send every 10ms stands in for
+	// the nine requests it could not send meanwhile.
+	rec.record(result{status: 200, latency: 100 * time.Millisecond, service: 100 * time.Millisecond, expected: 10 * time.Millisecond})
+
+	assert.Equal(t, int64(10), rec.latency.TotalCount())
+	assert.Equal(t, int64(1), rec.service.TotalCount())
+	assert.Equal(t, int64(1), rec.report().Requests)
+}
+
+func TestBuildRunReport(t *testing.T) {
+	first := newRecorder("warmup")
+	first.record(result{status: 200, latency: 10 * time.Millisecond, service: 10 * time.Millisecond})
+	first.record(result{latency: 2 * time.Second, service: 2 * time.Second})
+	first.addUnsent(3)
+	first.finish()
+
+	second := newRecorder("peak")
+	second.record(result{status: 200, latency: 20 * time.Millisecond, service: 20 * time.Millisecond, duplicate: true})
+	second.record(result{status: 429, latency: 5 * time.Millisecond, service: 5 * time.Millisecond})
+	second.addUnsent(2)
+	second.finish()
+
+	r := buildRunReport("flash-sale", "http://localhost:3333", []*recorder{first, second})
+
+	require.Len(t, r.Phases, 2)
+	assert.Equal(t, "warmup", r.Phases[0].Name)
+	assert.Equal(t, int64(3), r.Phases[0].Unsent)
+	assert.Equal(t, "peak", r.Phases[1].Name)
+
+	assert.Equal(t, "total", r.Total.Name)
+	assert.Equal(t, int64(4), r.Total.Requests)
+	assert.Equal(t, int64(5), r.Total.Unsent)
+	assert.Equal(t, int64(1), r.Total.Duplicates)
+	assert.Equal(t, map[string]int64{"200": 2, "429": 1, "error": 1}, r.Total.Status)
+	// The failed request from the first phase is the slowest of the run.
+	assert.InDelta(t, 2000, r.Total.LatencyMs.Max, 20)
+	assert.InDelta(t, 2000, r.Total.ServiceMs.Max, 20)
+}
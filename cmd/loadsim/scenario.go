@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rate profiles: how a phase's request rate moves over its duration.
+const (
+	profileConstant = "constant"
+	profileRamp     = "ramp"
+	profileSpike    = "spike"
+	profileSine     = "sine"
+)
+
+// Sending modes. An open loop sends on a fixed arrival schedule whether or
+// not earlier requests have returned; a closed loop has each worker wait
+// for its response before sending again, so a slow server slows the load.
+const (
+	modeOpen   = "open"
+	modeClosed = "closed"
+)
+
+// scenario is a multi-phase run, loaded from a YAML file or built from the
+// command line as a single phase.
+type scenario struct {
+	Name   string  `yaml:"name"`
+	Phases []phase `yaml:"phases"`
+}
+
+// phase is one stretch of traffic. Fields a scenario file leaves out are
+// filled in from the command line flags, so it only states what differs.
+type phase struct {
+	Name     string        `yaml:"name"`
+	Duration time.Duration `yaml:"duration"`
+	Mode     string        `yaml:"mode"`
+	Profile  string        `yaml:"profile"`
+	// Rate is the requests per second: the constant rate, the start of a
+	// ramp, the baseline of a spike and the trough of a sine wave.
+	Rate float64 `yaml:"rate"`
+	// Peak is the end of a ramp, the rate during a spike and the crest of a
+	// sine wave.
+	Peak float64 `yaml:"peak"`
+	// Period is how often a spike recurs and the length of one sine wave.
+	Period time.Duration `yaml:"period"`
+	// SpikeDuration is how long each spike lasts.
+	SpikeDuration time.Duration `yaml:"spike_duration"`
+	// Concurrency is the number of sending workers, and so the most
+	// requests in flight.
+	Concurrency int `yaml:"concurrency"`
+	// Types weights the event types sent, e.g. {payment.pending: 8}.
+	Types map[string]int `yaml:"types"`
+	// DuplicateRatio is the share of requests that redeliver an event
+	// already sent, as providers do on timeouts.
+	DuplicateRatio float64 `yaml:"duplicate_ratio"`
+	// Chaos is the share of new events marked to force each fault in a
+	// worker pool running chaos mode with markers, e.g. {error: 0.05}.
+	Chaos map[string]float64 `yaml:"chaos"`
+
+	// set holds the keys the scenario file gave, so an explicit zero such
+	// as rate: 0 is kept rather than replaced by the flag default.
+	set map[string]bool
+}
+
+// UnmarshalYAML decodes a phase and records which keys it set.
+func (p *phase) UnmarshalYAML(node *yaml.Node) error {
+	type plain phase
+	if err := node.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	p.set = make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		p.set[node.Content[i].Value] = true
+	}
+	return nil
+}
+
+// Faults an event can be marked with; the worker pool's chaos mode reads
+// them from event IDs of the form evt_chaos-<fault>-<random>.
+var chaosFaults = []string{"error", "panic", "hang", "latency"}
+
+func loadScenario(path string) (scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenario{}, fmt.Errorf("read scenario: %w", err)
+	}
+
+	var s scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return scenario{}, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if len(s.Phases) == 0 {
+		return scenario{}, fmt.Errorf("scenario %s: no phases", path)
+	}
+	return s, nil
+}
+
+// maxConcurrency is the most workers any phase runs, which sizes the
+// connection pool.
+func (s scenario) maxConcurrency() int {
+	n := 1
+	for _, p := range s.Phases {
+		n = max(n, p.Concurrency)
+	}
+	return n
+}
+
+// withDefaults fills the fields the scenario file left out from defaults.
+func (p phase) withDefaults(defaults phase) phase {
+	if !p.set["mode"] {
+		p.Mode = defaults.Mode
+	}
+	if !p.set["profile"] {
+		p.Profile = defaults.Profile
+	}
+	if !p.set["rate"] {
+		p.Rate = defaults.Rate
+	}
+	if !p.set["peak"] {
+		p.Peak = defaults.Peak
+	}
+	if !p.set["period"] {
+		p.Period = defaults.Period
+	}
+	if !p.set["spike_duration"] {
+		p.SpikeDuration = defaults.SpikeDuration
+	}
+	if !p.set["concurrency"] {
+		p.Concurrency = defaults.Concurrency
+	}
+	if !p.set["types"] {
+		p.Types = defaults.Types
+	}
+	if !p.set["duplicate_ratio"] {
+		p.DuplicateRatio = defaults.DuplicateRatio
+	}
+	if !p.set["chaos"] {
+		p.Chaos = defaults.Chaos
+	}
+	return p
+}
+
+func (p phase) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if p.Mode != modeOpen && p.Mode != modeClosed {
+		errs = append(errs, fmt.Errorf("unknown mode %q (want %s or %s)", p.Mode, modeOpen, modeClosed))
+	}
+	switch p.Profile {
+	case profileConstant, profileSpike, profileSine:
+	case profileRamp:
+		check(p.Duration > 0, "ramp profile needs a duration")
+	default:
+		errs = append(errs, fmt.Errorf("unknown profile %q (want %s, %s, %s or %s)",
+			p.Profile, profileConstant, profileRamp, profileSpike, profileSine))
+	}
+	check(p.Rate >= 0 && p.Peak >= 0, "rate and peak must not be negative")
+	check(p.Rate > 0 || p.Peak > 0, "rate or peak must be positive")
+	check(p.Duration >= 0, "duration must not be negative")
+	if p.Profile == profileSpike || p.Profile == profileSine {
+		check(p.Period > 0, "%s profile needs a period", p.Profile)
+	}
+	if p.Profile == profileSpike {
+		check(p.SpikeDuration > 0 && p.SpikeDuration <= p.Period, "spike_duration must be positive and at most the period")
+	}
+	check(p.Concurrency >= 1, "concurrency must be at least 1")
+	check(len(p.Types) > 0, "at least one event type is needed")
+	for eventType, weight := range p.Types {
+		check(weight >= 1, "type %s: weight must be at least 1", eventType)
+	}
+	check(p.DuplicateRatio >= 0 && p.DuplicateRatio < 1, "duplicate_ratio must be in [0, 1)")
+	total := 0.0
+	for fault, ratio := range p.Chaos {
+		check(slices.Contains(chaosFaults, fault), "chaos: unknown fault %q (want one of %v)", fault, chaosFaults)
+		check(ratio >= 0, "chaos: %s ratio must not be negative", fault)
+		total += ratio
+	}
+	check(total <= 1, "chaos: ratios must add up to at most 1")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("phase %q: %w", p.Name, errors.Join(errs...))
+	}
+	return nil
+}
+
+// rateAt is the target requests per second at elapsed into the phase.
+func (p phase) rateAt(elapsed time.Duration) float64 {
+	switch p.Profile {
+	case profileRamp:
+		progress := min(elapsed.Seconds()/p.Duration.Seconds(), 1)
+		return p.Rate + (p.Peak-p.Rate)*progress
+	case profileSpike:
+		if elapsed%p.Period < p.SpikeDuration {
+			return p.Peak
+		}
+		return p.Rate
+	case profileSine:
+		phaseAngle := 2 * math.Pi * elapsed.Seconds() / p.Period.Seconds()
+		return p.Rate + (p.Peak-p.Rate)*(1-math.Cos(phaseAngle))/2
+	default:
+		return p.Rate
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func flagDefaults() phase {
+	return phase{
+		Mode:           modeOpen,
+		Profile:        profileConstant,
+		Rate:           50,
+		Peak:           500,
+		Period:         time.Minute,
+		SpikeDuration:  5 * time.Second,
+		Concurrency:    100,
+		Types:          map[string]int{"payment.completed": 1},
+		DuplicateRatio: 0.1,
+		Chaos:          map[string]float64{"error": 0.05},
+	}
+}
+
+func writeScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestPhase_WithDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected func(p *phase)
+	}{
+		{
+			name:     "omitted keys take the flag defaults",
+			yaml:     "phases:\n  - duration: 1m\n",
+			expected: func(p *phase) {},
+		},
+		{
+			name: "explicit zeros are kept",
+			yaml: "phases:\n  - duration: 1m\n    rate: 0\n    duplicate_ratio: 0\n    chaos: {}\n",
+			expected: func(p *phase) {
+				p.Rate = 0
+				p.DuplicateRatio = 0
+				p.Chaos = map[string]float64{}
+			},
+		},
+		{
+			name: "set keys override the flags",
+			yaml: "phases:\n  - duration: 1m\n    profile: ramp\n    peak: 80\n    types: {payment.pending: 3}\n",
+			expected: func(p *phase) {
+				p.Profile = profileRamp
+				p.Peak = 80
+				p.Types = map[string]int{"payment.pending": 3}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := loadScenario(writeScenario(t, tt.yaml))
+			require.NoError(t, err)
+			require.Len(t, s.Phases, 1)
+
+			expected := flagDefaults()
+			expected.Duration = time.Minute
+			tt.expected(&expected)
+
+			got := s.Phases[0].withDefaults(flagDefaults())
+			got.set = nil
+			assert.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestPhase_WithDefaultsFillsFlagPhase(t *testing.T) {
+	got := phase{Name: "flags", Duration: time.Minute}.withDefaults(flagDefaults())
+
+	expected := flagDefaults()
+	expected.Name = "flags"
+	expected.Duration = time.Minute
+	assert.Equal(t, expected, got)
+}
+
+func TestPhase_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(p *phase)
+		expectedErrors []string
+	}{
+		{
+			name:   "valid defaults",
+			modify: func(p *phase) {},
+		},
+		{
+			name:   "ramp from zero",
+			modify: func(p *phase) { p.Profile = profileRamp; p.Rate = 0; p.Duration = time.Minute },
+		},
+		{
+			name:           "unknown mode and profile",
+			modify:         func(p *phase) { p.Mode = "batch"; p.Profile = "square" },
+			expectedErrors: []string{`unknown mode "batch"`, `unknown profile "square"`},
+		},
+		{
+			name:           "ramp without a duration",
+			modify:         func(p *phase) { p.Profile = profileRamp; p.Duration = 0 },
+			expectedErrors: []string{"ramp profile needs a duration"},
+		},
+		{
+			name:           "no rate at all",
+			modify:         func(p *phase) { p.Rate = 0; p.Peak = 0 },
+			expectedErrors: []string{"rate or peak must be positive"},
+		},
+		{
+			name:           "negative rate",
+			modify:         func(p *phase) { p.Rate = -1 },
+			expectedErrors: []string{"rate and peak must not be negative"},
+		},
+		{
+			name:           "spike longer than its period",
+			modify:         func(p *phase) { p.Profile = profileSpike; p.SpikeDuration = 2 * time.Minute },
+			expectedErrors: []string{"spike_duration must be positive and at most the period"},
+		},
+		{
+			name:           "sine without a period",
+			modify:         func(p *phase) { p.Profile = profileSine; p.Period = 0 },
+			expectedErrors: []string{"sine profile needs a period"},
+		},
+		{
+			name:           "no workers",
+			modify:         func(p *phase) { p.Concurrency = 0 },
+			expectedErrors: []string{"concurrency must be at least 1"},
+		},
+		{
+			name:           "no types",
+			modify:         func(p *phase) { p.Types = map[string]int{} },
+			expectedErrors: []string{"at least one event type is needed"},
+		},
+		{
+			name:           "zero weight",
+			modify:         func(p *phase) { p.Types = map[string]int{"payment.completed": 0} },
+			expectedErrors: []string{"type payment.completed: weight must be at least 1"},
+		},
+		{
+			name:           "every request a duplicate",
+			modify:         func(p *phase) { p.DuplicateRatio = 1 },
+			expectedErrors: []string{"duplicate_ratio must be in [0, 1)"},
+		},
+		{
+			name:   "unknown fault and too much chaos",
+			modify: func(p *phase) { p.Chaos = map[string]float64{"error": 0.7, "oom": 0.5} },
+			expectedErrors: []string{
+				`chaos: unknown fault "oom"`,
+				"chaos: ratios must add up to at most 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := flagDefaults()
+			p.Name = "test"
+			tt.modify(&p)
+
+			err := p.validate()
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `phase "test"`)
+			for _, expected := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestPhase_RateAt(t *testing.T) {
+	ramp := phase{Profile: profileRamp, Rate: 10, Peak: 110, Duration: 10 * time.Second}
+	spike := phase{Profile: profileSpike, Rate: 10, Peak: 100, Period: time.Minute, SpikeDuration: 5 * time.Second}
+	sine := phase{Profile: profileSine, Rate: 10, Peak: 110, Period: time.Minute}
+
+	tests := []struct {
+		name     string
+		phase    phase
+		elapsed  time.Duration
+		expected float64
+	}{
+		{name: "constant", phase: phase{Profile: profileConstant, Rate: 25}, elapsed: time.Hour, expected: 25},
+		{name: "ramp start", phase: ramp, elapsed: 0, expected: 10},
+		{name: "ramp quarter", phase: ramp, elapsed: 2500 * time.Millisecond, expected: 35},
+		{name: "ramp halfway", phase: ramp, elapsed: 5 * time.Second, expected: 60},
+		{name: "ramp end", phase: ramp, elapsed: 10 * time.Second, expected: 110},
+		{name: "ramp holds the peak past its duration", phase: ramp, elapsed: 20 * time.Second, expected: 110},
+		{name: "ramp down", phase: phase{Profile: profileRamp, Rate: 100, Peak: 0, Duration: 4 * time.Second}, elapsed: time.Second, expected: 75},
+		{name: "spike start", phase: spike, elapsed: 0, expected: 100},
+		{name: "spike end", phase: spike, elapsed: 5 * time.Second, expected: 10},
+		{name: "spike recurs", phase: spike, elapsed: 61 * time.Second, expected: 100},
+		{name: "sine trough", phase: sine, elapsed: 0, expected: 10},
+		{name: "sine crest", phase: sine, elapsed: 30 * time.Second, expected: 110},
+		{name: "sine quarter", phase: sine, elapsed: 15 * time.Second, expected: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, tt.phase.rateAt(tt.elapsed), 1e-9)
+		})
+	}
+}
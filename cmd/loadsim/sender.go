@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// idleRecheck is how long a phase waits before re-reading its rate when the
+// profile is at zero.
+const idleRecheck = 100 * time.Millisecond
+
+type sender struct {
+	client         *http.Client
+	url            string
+	apiKey         string
+	gen            *generator
+	lag            *lagTracker
+	reportInterval time.Duration
+}
+
+// newHTTPClient keeps a warm connection per worker, so the load measures the
+// server rather than connection setup and the client doesn't run out of
+// ephemeral ports.
+func newHTTPClient(conns int, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	transport.MaxIdleConns = conns
+	transport.MaxIdleConnsPerHost = conns
+	transport.IdleConnTimeout = 90 * time.Second
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// runPhase sends at the phase's rate with ph.Concurrency workers until its
+// duration is up (or ctx is cancelled, for a phase without a duration).
+// Requests in flight when the phase ends run on ctx and are let finish.
+func (s *sender) runPhase(ctx context.Context, ph phase) *recorder {
+	phaseCtx := ctx
+	if ph.Duration > 0 {
+		var cancel context.CancelFunc
+		phaseCtx, cancel = context.WithTimeout(ctx, ph.Duration)
+		defer cancel()
+	}
+	s.gen.setMix(ph.Types)
+	s.gen.setChaos(ph.Chaos)
+
+	log.Info().
+		Str("phase", ph.Name).
+		Str("mode", ph.Mode).
+		Str("profile", ph.Profile).
+		Dur("duration", ph.Duration).
+		Float64("rate", ph.Rate).
+		Float64("peak", ph.Peak).
+		Int("concurrency", ph.Concurrency).
+		Interface("types", ph.Types).
+		Float64("duplicate_ratio", ph.DuplicateRatio).
+		Msg("Phase started")
+	if len(ph.Chaos) > 0 {
+		log.Warn().
+			Str("phase", ph.Name).
+			Interface("chaos", ph.Chaos).
+			Msg("CHAOS MARKERS ON: events are marked to force worker faults; the worker pool must run with worker.chaos.enabled and markers")
+	}
+
+	rec := newRecorder(ph.Name)
+	if s.reportInterval > 0 {
+		ticker := time.NewTicker(s.reportInterval)
+		defer ticker.Stop()
+		go func() {
+			for {
+				select {
+				case <-phaseCtx.Done():
+					return
+				case <-ticker.C:
+					rec.logProgress()
+				}
+			}
+		}()
+	}
+
+	if ph.Mode == modeClosed {
+		s.closedLoop(ctx, phaseCtx, ph, rec)
+	} else {
+		s.openLoop(ctx, phaseCtx, ph, rec)
+	}
+	rec.finish()
+
+	p := rec.report()
+	log.Info().
+		Str("phase", ph.Name).
+		Int64("requests", p.Requests).
+		Int64("unsent", p.Unsent).
+		Int64("duplicates", p.Duplicates).
+		Interface("status", p.Status).
+		Float64("throughput_rps", p.ThroughputRPS).
+		Float64("p50_ms", p.LatencyMs.P50).
+		Float64("p95_ms", p.LatencyMs.P95).
+		Float64("p99_ms", p.LatencyMs.P99).
+		Msg("Phase completed")
+	return rec
+}
+
+// openLoop schedules arrivals at the phase's rate and hands each to the
+// next free worker. When every worker is busy, arrivals queue rather than
+// being skipped, and latency is measured from when a request was due, not
+// when it went out, so a stalled server shows up in the percentiles
+// instead of silently lowering the rate (coordinated omission). Arrivals
+// that fell due but were never scheduled by the end of the phase are
+// counted as unsent.
+func (s *sender) openLoop(ctx, phaseCtx context.Context, ph phase, rec *recorder) {
+	arrivals := make(chan time.Time, ph.Concurrency)
+	var wg sync.WaitGroup
+	for range ph.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for due := range arrivals {
+				s.sendOne(ctx, ph.DuplicateRatio, rec, due, 0)
+			}
+		}()
+	}
+
+	start := time.Now()
+	next := start
+loop:
+	for {
+		rate := ph.rateAt(next.Sub(start))
+		select {
+		case <-phaseCtx.Done():
+			break loop
+		case <-time.After(time.Until(next)):
+		}
+		if rate <= 0 {
+			next = next.Add(idleRecheck)
+			continue
+		}
+
+		select {
+		case <-phaseCtx.Done():
+			break loop
+		case arrivals <- next:
+		}
+		next = next.Add(interval(rate))
+	}
+	close(arrivals)
+
+	// Arrivals that fell due while the workers were behind.
+	var unsent int64
+	for now := time.Now(); next.Before(now); {
+		rate := ph.rateAt(next.Sub(start))
+		if rate <= 0 {
+			next = next.Add(idleRecheck)
+			continue
+		}
+		unsent++
+		next = next.Add(interval(rate))
+	}
+	rec.addUnsent(unsent)
+	wg.Wait()
+}
+
+// closedLoop runs workers that each send, wait for the response, then wait
+// out the rest of their share of the schedule. A slow server lowers the
+// achieved rate; each latency is recorded with HdrHistogram's correction
+// for the requests the worker would have sent meanwhile (coordinated
+// omission).
+func (s *sender) closedLoop(ctx, phaseCtx context.Context, ph phase, rec *recorder) {
+	workers := float64(ph.Concurrency)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for w := range ph.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Stagger the workers across the first interval.
+			next := start.Add(time.Duration(float64(w) / workers * float64(interval(ph.rateAt(0)/workers))))
+			for {
+				select {
+				case <-phaseCtx.Done():
+					return
+				case <-time.After(time.Until(next)):
+				}
+				rate := ph.rateAt(time.Since(start)) / workers
+				if rate <= 0 {
+					next = time.Now().Add(idleRecheck)
+					continue
+				}
+
+				every := interval(rate)
+				s.sendOne(ctx, ph.DuplicateRatio, rec, time.Time{}, every)
+				// A worker never catches up on sends it missed while waiting.
+				next = next.Add(every)
+				if now := time.Now(); next.Before(now) {
+					next = now
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// interval is the time between requests at rate per second; a rate of zero
+// gives idleRecheck.
+func interval(rate float64) time.Duration {
+	if rate <= 0 {
+		return idleRecheck
+	}
+	return time.Duration(float64(time.Second) / rate)
+}
+
+// sendOne delivers one webhook, a redelivery of a recent one with
+// probability duplicateRatio, and records the outcome in rec. Latency runs
+// from due when set (open loop), otherwise from the send; expected is the
+// closed loop's interval for coordinated-omission correction. Accepted new
+// events are offered to the lag tracker.
+func (s *sender) sendOne(ctx context.Context, duplicateRatio float64, rec *recorder, due time.Time, expected time.Duration) {
+	body, eventID, duplicate, err := s.gen.body(duplicateRatio)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build webhook")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to build webhook request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", s.gen.signature(body))
+	if s.apiKey != "" {
+		req.Header.Set("X-API-Key", s.apiKey)
+	}
+
+	sentAt := time.Now()
+	if due.IsZero() {
+		due = sentAt
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Debug().Err(err).Msg("Webhook request failed")
+		}
+		failed := time.Now()
+		rec.record(result{
+			latency:   failed.Sub(due),
+			service:   failed.Sub(sentAt),
+			expected:  expected,
+			duplicate: duplicate,
+			aborted:   ctx.Err() != nil,
+		})
+		return
+	}
+	// The body is read to the end so the connection goes back to the pool.
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	done := time.Now()
+
+	log.Debug().
+		Int("status", resp.StatusCode).
+		Dur("service", done.Sub(sentAt)).
+		Dur("latency", done.Sub(due)).
+		Str("response", string(respBody)).
+		Msg("Webhook response")
+
+	rec.record(result{
+		status:    resp.StatusCode,
+		latency:   done.Sub(due),
+		service:   done.Sub(sentAt),
+		expected:  expected,
+		duplicate: duplicate,
+	})
+	if resp.StatusCode == http.StatusOK && !duplicate {
+		s.lag.track(ctx, eventID, sentAt)
+	}
+}
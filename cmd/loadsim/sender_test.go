@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSender(t *testing.T, handler http.HandlerFunc, timeout time.Duration) *sender {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	gen := newGenerator("")
+	gen.setMix(map[string]int{"payment.completed": 1})
+	return &sender{
+		client: newHTTPClient(1, timeout),
+		url:    srv.URL + "/webhooks/payments",
+		gen:    gen,
+	}
+}
+
+func TestSender_SendOneRecordsFailures(t *testing.T) {
+	tests := []struct {
+		name             string
+		handler          http.HandlerFunc
+		cancel           bool
+		expectedStatus   map[string]int64
+		expectedRecorded int64
+		expectedMinMs    float64
+	}{
+		{
+			name:             "accepted",
+			handler:          func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) },
+			expectedStatus:   map[string]int64{"200": 1},
+			expectedRecorded: 1,
+		},
+		{
+			name: "timed out",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			expectedStatus:   map[string]int64{"error": 1},
+			expectedRecorded: 1,
+			expectedMinMs:    50,
+		},
+		{
+			name: "interrupted",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				<-r.Context().Done()
+			},
+			cancel:           true,
+			expectedStatus:   map[string]int64{"error": 1},
+			expectedRecorded: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSender(t, tt.handler, 50*time.Millisecond)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(10*time.Millisecond, cancel)
+			}
+
+			rec := newRecorder("test")
+			s.sendOne(ctx, 0, rec, time.Time{}, 0)
+
+			p := rec.report()
+			assert.Equal(t, tt.expectedStatus, p.Status)
+			assert.Equal(t, tt.expectedRecorded, rec.latency.TotalCount())
+			if tt.expectedMinMs > 0 {
+				assert.GreaterOrEqual(t, p.LatencyMs.Max, tt.expectedMinMs)
+			}
+		})
+	}
+}
+
+func TestSender_OpenLoopCountsUnsentArrivals(t *testing.T) {
+	tests := []struct {
+		name             string
+		stall            bool
+		rate             float64
+		expectedRequests int64
+		expectedUnsent   []int64
+	}{
+		{
+			// At 20/s over 500ms, ten arrivals fall due. The one worker takes
+			// the first and the queue holds the second while the server
+			// stalls; the rest are never scheduled, and the arrival due as
+			// the phase ends may be counted too.
+			name:             "stalled server",
+			stall:            true,
+			rate:             20,
+			expectedRequests: 2,
+			expectedUnsent:   []int64{8, 9},
+		},
+		{
+			name:             "idle profile",
+			rate:             0,
+			expectedRequests: 0,
+			expectedUnsent:   []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ph := flagDefaults()
+			ph.Rate = tt.rate
+			ph.Concurrency = 1
+			ph.Duration = 500 * time.Millisecond
+
+			ctx := context.Background()
+			phaseCtx, cancel := context.WithTimeout(ctx, ph.Duration)
+			defer cancel()
+
+			s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				if tt.stall {
+					<-phaseCtx.Done()
+				}
+			}, 5*time.Second)
+
+			rec := newRecorder("test")
+			s.openLoop(ctx, phaseCtx, ph, rec)
+
+			p := rec.report()
+			assert.Equal(t, tt.expectedRequests, p.Requests)
+			assert.Contains(t, tt.expectedUnsent, p.Unsent)
+		})
+	}
+}
+
+func TestSender_OpenLoopKeepsUpWithAFastServer(t *testing.T) {
+	s := newTestSender(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}, time.Second)
+	ph := flagDefaults()
+	ph.Rate = 20
+	ph.Concurrency = 4
+	ph.Duration = 500 * time.Millisecond
+
+	ctx := context.Background()
+	phaseCtx, cancel := context.WithTimeout(ctx, ph.Duration)
+	defer cancel()
+
+	rec := newRecorder("test")
+	start := time.Now()
+	s.openLoop(ctx, phaseCtx, ph, rec)
+	elapsed := time.Since(start)
+
+	// Every arrival that fell due, one every 50ms, is either sent or
+	// counted as unsent.
+	p := rec.report()
+	assert.GreaterOrEqual(t, p.Requests+p.Unsent, int64(10))
+	assert.LessOrEqual(t, p.Requests+p.Unsent, int64(elapsed/(50*time.Millisecond))+1)
+	assert.Equal(t, map[string]int64{"200": p.Requests}, p.Status)
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,53 +15,112 @@ import (
 	"worker-pool/internal/config"
 	"worker-pool/internal/db"
 	"worker-pool/internal/handler"
+	"worker-pool/internal/health"
 	"worker-pool/internal/services"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 )
 
 func main() {
 
-	log.Info().Msg("Starting server...")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
 
-	cfg, err := config.LoadConfig()
+	cfg, err := config.Load(*configPath)
+	if *printConfig {
+		if printErr := config.Print(os.Stdout, cfg); printErr != nil {
+			err = errors.Join(err, printErr)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error loading config")
 	}
 
-	store, err := db.InitPostgres(cfg.DatabaseURL)
+	if level, err := zerolog.ParseLevel(cfg.Log.Level); err == nil {
+		zerolog.SetGlobalLevel(level)
+	}
+
+	log.Info().Msg("Starting server...")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := db.InitPostgres(ctx, cfg.Database)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error initializing database")
 	}
 
-	ws := services.NewWebhookService(store)
+	validator, err := services.NewPaymentValidator(cfg.Validation)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error building payload validator")
+	}
+
+	ws := services.NewWebhookService(store, validator)
+	wks := services.NewWorkerService(store, cfg.Worker.HeartbeatTimeout)
+	qs := services.NewQueueService(store)
 
-	h := handler.NewHandler(cfg, ws)
+	h := handler.NewHandler(cfg, ws, wks, qs)
+	backpressure := services.NewBackpressure(store, cfg.Backpressure)
 
 	e := echo.New()
 	e.HideBanner = true
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.IPExtractor, err = handler.IPExtractor(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error configuring trusted proxies")
+	}
 
 	e.Use(middleware.RequestLogger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderCookie},
-		AllowCredentials: false,
+	if len(cfg.Security.CORSAllowOrigins) > 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:     cfg.Security.CORSAllowOrigins,
+			AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+			AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, "X-API-Key"},
+			AllowCredentials: false,
+		}))
+	}
+	e.Use(handler.Authenticate(services.NewAPIKeyService(store)))
+	e.Use(handler.CaptureRawBody(cfg.Server.MaxBodyBytes))
+
+	// Strict middlewares wrap in list order, so the last entry runs first.
+	api.RegisterHandlers(e, api.NewStrictHandler(h, []api.StrictMiddlewareFunc{
+		handler.Backpressure(backpressure),
+		handler.SignatureAuth(cfg.Security.WebhookSigningSecret),
+		handler.RequireScopes(cfg.Security.RequireAPIKeys),
+		handler.RateLimit(handler.NewRateLimiter(cfg.RateLimit)),
+		handler.RequestTimeout(cfg.Server.RequestTimeout),
+		handler.RequestLogging(),
 	}))
 
-	api.RegisterHandlers(e, h)
+	checker := health.NewChecker()
+	checker.Add("database", store.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		return db.CheckSchema(ctx, store)
+	})
+	e.GET("/healthz", echo.WrapHandler(checker.LivenessHandler()))
+	e.GET("/readyz", echo.WrapHandler(checker.ReadinessHandler()))
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Request contexts derive from requestCtx so in-flight work is cancelled
+	// if graceful shutdown runs out of time.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	e.Server.BaseContext = func(net.Listener) context.Context { return requestCtx }
 
 	var g errgroup.Group
 
 	g.Go(func() error {
-		serverAddr := ":" + cfg.Port
+		serverAddr := ":" + cfg.Server.Port
 		log.Info().Str("address", serverAddr).Msg("Starting HTTP server")
 		if err := e.Start(serverAddr); err != nil && err != http.ErrServerClosed {
 			log.Error().Err(err).Msg("HTTP server error")
@@ -69,6 +132,18 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	g.Go(func() error {
+		return backpressure.Run(ctx)
+	})
+
+	g.Go(func() error {
+		if err := serveMetrics(ctx, cfg.Server.MetricsAddr); err != nil {
+			log.Error().Err(err).Msg("Metrics listener error")
+			return err
+		}
+		return nil
+	})
+
 	g.Go(func() error {
 		select {
 		case <-sigChan:
@@ -82,12 +157,18 @@ func main() {
 
 	g.Go(func() error {
 		<-ctx.Done()
+		checker.SetDraining()
+		if cfg.Server.DrainDelay > 0 {
+			log.Info().Dur("drain_delay", cfg.Server.DrainDelay).Msg("Waiting for load balancers to drain")
+			time.Sleep(cfg.Server.DrainDelay)
+		}
 		log.Info().Msg("Shutting down server...")
 
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 		defer shutdownCancel()
 
 		if err := e.Shutdown(shutdownCtx); err != nil {
+			cancelRequests()
 			log.Error().Err(err).Msg("Error during server shutdown")
 			return err
 		}
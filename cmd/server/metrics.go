@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"worker-pool/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// serveMetrics runs the API server's /metrics listener until ctx is
+// cancelled. It is kept off the public port so only the network the
+// listener is exposed on can scrape it. An empty addr disables it.
+func serveMetrics(ctx context.Context, addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Error shutting down metrics listener")
+		}
+	}()
+
+	log.Info().Str("address", addr).Msg("Starting metrics listener")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/worker"
+
+	"github.com/rs/zerolog"
+)
+
+// processWebhook is the handler for every event type: it logs the payload
+// and simulates work by waiting for the current process delay. The pool's
+// middleware has already put the event's fields on the context logger.
+func processWebhook(delay func() time.Duration) worker.EventHandler {
+	return func(ctx context.Context, event sqlc.WebhookEvent) error {
+		var payload map[string]interface{}
+		if len(event.Payload) > 0 {
+			_ = json.Unmarshal(event.Payload, &payload)
+		}
+
+		zerolog.Ctx(ctx).Info().
+			Interface("payload", payload).
+			Msg("Processing webhook")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay()):
+		}
+
+		return nil
+	}
+}
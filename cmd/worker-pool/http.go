@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"worker-pool/internal/health"
+	"worker-pool/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+const httpShutdownTimeout = 5 * time.Second
+
+// serveHTTP runs the worker pool's operational listener until ctx is
+// cancelled. An empty addr disables it.
+func serveHTTP(ctx context.Context, addr string, checker *health.Checker) error {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", checker.LivenessHandler())
+	mux.Handle("/readyz", checker.ReadinessHandler())
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Error shutting down worker HTTP listener")
+		}
+	}()
+
+	log.Info().Str("address", addr).Msg("Starting worker HTTP listener")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -2,151 +2,130 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
-	sqlc "worker-pool/internal/db/sqlc/generated"
 	"worker-pool/internal/config"
 	"worker-pool/internal/db"
+	"worker-pool/internal/health"
+	"worker-pool/internal/worker"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 )
 
-const (
-	defaultWorkerCount   = 5
-	defaultPollInterval  = 2 * time.Second
-	defaultProcessDelay  = 100 * time.Millisecond
-)
-
 func main() {
-	cfg, err := config.LoadConfig()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if *printConfig {
+		if printErr := config.Print(os.Stdout, cfg); printErr != nil {
+			err = errors.Join(err, printErr)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error loading config")
 	}
 
-	workerCount := intEnv("WORKER_POOL_SIZE", defaultWorkerCount)
-	pollInterval := durationEnv("WORKER_POLL_INTERVAL", defaultPollInterval)
-	processDelay := durationEnv("WORKER_PROCESS_DELAY", defaultProcessDelay)
-
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
-
-	store, err := db.InitPostgres(cfg.DatabaseURL)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Error initializing database")
+	if err := applyLogLevel(cfg.Log.Level); err != nil {
+		log.Fatal().Err(err).Msg("Error setting log level")
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	store, err := db.InitPostgres(ctx, cfg.Database)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error initializing database")
+	}
+
 	log.Info().
-		Int("workers", workerCount).
-		Dur("poll_interval", pollInterval).
-		Dur("process_delay", processDelay).
+		Int("workers", cfg.Worker.PoolSize).
+		Dur("poll_interval", cfg.Worker.PollInterval).
+		Dur("process_delay", cfg.Worker.ProcessDelay).
+		Interface("type_limits", cfg.Worker.TypeLimits).
+		Str("claim_strategy", cfg.Worker.ClaimStrategy).
+		Str("fair_by", cfg.Worker.FairBy).
+		Int32("max_attempts", cfg.Retry.MaxAttempts).
 		Msg("Starting worker pool")
 
-	g, gCtx := errgroup.WithContext(ctx)
-	for i := range workerCount {
-		workerID := i + 1
-		g.Go(func() error {
-			return runWorker(gCtx, store, workerID, pollInterval, processDelay)
-		})
+	handlers := worker.NewRegistry()
+	p, err := worker.New(worker.Options{
+		Store:    store,
+		Handlers: handlers,
+		Settings: cfg.Worker,
+		Retry:    cfg.Retry,
+		Version:  buildVersion(),
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating worker pool")
 	}
-
-	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
-		log.Fatal().Err(err).Msg("Worker pool stopped with error")
+	handlers.HandleDefault(processWebhook(func() time.Duration {
+		return p.Settings().ProcessDelay
+	}))
+	if err := p.Start(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Error starting worker pool")
 	}
-	log.Info().Msg("Worker pool stopped")
-}
+	r := &reloader{path: *configPath, pool: p, current: cfg}
 
-func runWorker(ctx context.Context, store db.Store, workerID int, pollInterval, processDelay time.Duration) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	checker := health.NewChecker()
+	checker.Add("database", store.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		return db.CheckSchema(ctx, store)
+	})
+	checker.Add("worker_loop", p.CheckLoop)
 
-		event, err := store.ClaimNextWebhook(ctx)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(pollInterval):
-				}
-				continue
+	// The listener outlives gCtx so /readyz can report the drain while
+	// in-flight events finish; it stops once the pool has.
+	httpCtx, stopHTTP := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopHTTP()
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		defer stopHTTP()
+		select {
+		case <-gCtx.Done():
+			// Let in-flight events finish. Handlers still running after
+			// SHUTDOWN_TIMEOUT are cancelled and their events released.
+			drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+			defer cancel()
+			err := p.Drain(drainCtx)
+			if errors.Is(err, context.DeadlineExceeded) {
+				log.Warn().Dur("shutdown_timeout", cfg.Server.ShutdownTimeout).Msg("Drain timed out; cancelled the remaining handlers")
+				return p.Err()
 			}
 			return err
+		case <-p.Done():
+			return p.Err()
 		}
+	})
+	g.Go(func() error {
+		<-gCtx.Done()
+		checker.SetDraining()
+		return nil
+	})
+	g.Go(func() error {
+		return r.run(gCtx)
+	})
+	g.Go(func() error {
+		return serveHTTP(httpCtx, cfg.Worker.HTTPAddr, checker)
+	})
 
-		log.Debug().
-			Int("worker", workerID).
-			Str("event_id", event.EventID).
-			Msg("Claimed webhook")
-
-		if err := processWebhook(ctx, store, event, processDelay); err != nil {
-			log.Warn().Err(err).Str("event_id", event.EventID).Msg("Processing failed")
-		}
-	}
-}
-
-func processWebhook(ctx context.Context, store db.Store, event sqlc.WebhookEvent, processDelay time.Duration) error {
-	var payload map[string]interface{}
-	if len(event.Payload) > 0 {
-		_ = json.Unmarshal(event.Payload, &payload)
-	}
-
-	log.Info().
-		Str("event_id", event.EventID).
-		Interface("type", event.Type).
-		Interface("payload", payload).
-		Msg("Processing webhook")
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(processDelay):
-	}
-
-	_, err := store.MarkWebhookDone(ctx, event.ID)
-	if err != nil {
-		errStr := err.Error()
-		_, _ = store.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{ID: event.ID, LastError: &errStr})
-		return err
-	}
-
-	log.Info().Str("event_id", event.EventID).Msg("Webhook marked done")
-	return nil
-}
-
-func intEnv(key string, defaultVal int) int {
-	s := os.Getenv(key)
-	if s == "" {
-		return defaultVal
-	}
-	n, err := strconv.Atoi(s)
-	if err != nil || n < 1 {
-		return defaultVal
-	}
-	return n
-}
-
-func durationEnv(key string, defaultVal time.Duration) time.Duration {
-	s := os.Getenv(key)
-	if s == "" {
-		return defaultVal
-	}
-	d, err := time.ParseDuration(s)
-	if err != nil || d < 0 {
-		return defaultVal
+	if err := g.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatal().Err(err).Msg("Worker pool exited with error")
 	}
-	return d
 }
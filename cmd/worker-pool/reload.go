@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/worker"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// reloadDebounce absorbs the burst of events editors produce for one save.
+const reloadDebounce = 500 * time.Millisecond
+
+// reloader re-reads the configuration on SIGHUP or when the config file
+// changes, and applies the settings the pool can change live.
+type reloader struct {
+	path    string
+	pool    *worker.Pool
+	current config.Config
+}
+
+func (r *reloader) run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var fileEvents <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if r.path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("create config watcher: %w", err)
+		}
+		defer watcher.Close()
+
+		// Watch the directory rather than the file: editors and config
+		// management often replace the file, which drops a file watch.
+		if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+			return fmt.Errorf("watch config file: %w", err)
+		}
+		fileEvents, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.reload("sighup")
+		case event := <-fileEvents:
+			if filepath.Clean(event.Name) == filepath.Clean(r.path) &&
+				event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			r.reload("file_change")
+		case err := <-watchErrors:
+			log.Warn().Err(err).Msg("Config watcher error")
+		}
+	}
+}
+
+// reload loads and validates the new configuration. A config that fails to
+// load or apply leaves the previous one in place.
+func (r *reloader) reload(trigger string) {
+	next, err := config.Load(r.path)
+	if err != nil {
+		log.Error().Err(err).Str("trigger", trigger).Msg("Config reload rejected, keeping current config")
+		return
+	}
+
+	candidate := r.current
+	candidate.Log = next.Log
+	candidate.Worker = next.Worker
+	// The listener is already bound; a new address only takes effect on restart.
+	candidate.Worker.HTTPAddr = r.current.Worker.HTTPAddr
+	// The claim scheduler is built once at startup.
+	candidate.Worker.ClaimStrategy = r.current.Worker.ClaimStrategy
+	candidate.Worker.FairBy = r.current.Worker.FairBy
+	candidate.Worker.FairWeights = r.current.Worker.FairWeights
+
+	changes := config.Diff(r.current, candidate)
+	var restartRequired []string
+	for _, c := range config.Diff(candidate, next) {
+		restartRequired = append(restartRequired, c.Path)
+	}
+
+	if len(changes) == 0 {
+		log.Info().Str("trigger", trigger).Strs("restart_required", restartRequired).Msg("Config reloaded with no live changes")
+		return
+	}
+
+	if err := r.apply(candidate); err != nil {
+		log.Error().Err(err).Str("trigger", trigger).Msg("Config reload failed, rolling back")
+		if rollbackErr := r.apply(r.current); rollbackErr != nil {
+			log.Error().Err(rollbackErr).Msg("Config rollback failed")
+		}
+		return
+	}
+	r.current = candidate
+
+	diff := zerolog.Dict()
+	for _, c := range changes {
+		diff.Str(c.Path, c.Old+" -> "+c.New)
+	}
+	log.Info().
+		Str("trigger", trigger).
+		Dict("changes", diff).
+		Strs("restart_required", restartRequired).
+		Msg("Config reloaded")
+}
+
+func (r *reloader) apply(cfg config.Config) error {
+	if err := applyLogLevel(cfg.Log.Level); err != nil {
+		return err
+	}
+	r.pool.Apply(cfg.Worker)
+	return nil
+}
+
+func applyLogLevel(level string) error {
+	parsed, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return fmt.Errorf("parse log level: %w", err)
+	}
+	zerolog.SetGlobalLevel(parsed)
+	return nil
+}
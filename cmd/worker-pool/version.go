@@ -0,0 +1,21 @@
+package main
+
+import "runtime/debug"
+
+// version is set at build time with -ldflags "-X main.version=...". When it
+// is empty the VCS revision recorded by the Go toolchain is used instead.
+var version string
+
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "dev"
+}
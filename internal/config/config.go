@@ -1,42 +1,421 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
 	"os"
-	"strconv"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const redacted = "[REDACTED]"
+
 type Config struct {
-	Port        string
-	DatabaseURL string
+	Log          LogConfig          `yaml:"log" toml:"log"`
+	Server       ServerConfig       `yaml:"server" toml:"server"`
+	RateLimit    RateLimitConfig    `yaml:"rate_limit" toml:"rate_limit"`
+	Backpressure BackpressureConfig `yaml:"backpressure" toml:"backpressure"`
+	Worker       WorkerConfig       `yaml:"worker" toml:"worker"`
+	Database     DatabaseConfig     `yaml:"database" toml:"database"`
+	Retry        RetryConfig        `yaml:"retry" toml:"retry"`
+	Security     SecurityConfig     `yaml:"security" toml:"security"`
+	Validation   ValidationConfig   `yaml:"validation" toml:"validation"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+type ServerConfig struct {
+	Port            string        `yaml:"port" toml:"port" env:"PORT"`
+	RequestTimeout  time.Duration `yaml:"request_timeout" toml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// DrainDelay is how long /readyz reports unavailable before the server
+	// stops accepting connections, giving load balancers time to notice.
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// MaxBodyBytes rejects larger request bodies with 413 before they are
+	// read into memory.
+	MaxBodyBytes int64 `yaml:"max_body_bytes" toml:"max_body_bytes" env:"MAX_BODY_BYTES"`
+	// TrustedProxies lists the CIDR ranges of reverse proxies whose
+	// X-Forwarded-For entries are believed. Empty uses the connection's
+	// address as the client IP and ignores forwarding headers.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// MetricsAddr is where the API server serves /metrics, apart from the
+	// public port. Empty disables it.
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR"`
+}
+
+// Rate limit keys.
+const (
+	RateLimitByIP       = "ip"
+	RateLimitByProvider = "provider"
+	RateLimitByAPIKey   = "api_key"
+)
+
+// RateLimitConfig is a token bucket per sender on the ingest endpoint. A
+// zero RPS disables it.
+type RateLimitConfig struct {
+	RPS   float64 `yaml:"rps" toml:"rps" env:"RATE_LIMIT_RPS"`
+	Burst int     `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"`
+	// KeyBy picks what identifies a sender: the client IP, the
+	// X-Webhook-Provider header or the X-API-Key header. Requests without the
+	// header fall back to the client IP.
+	KeyBy string `yaml:"key_by" toml:"key_by" env:"RATE_LIMIT_KEY_BY"`
+}
+
+// Claim strategies and the partitions they are fair across.
+const (
+	ClaimFIFO       = "fifo"
+	ClaimRoundRobin = "round_robin"
+	ClaimDRR        = "drr"
+
+	FairByTenant = "tenant"
+	FairByType   = "type"
+)
+
+// WorkerConfig holds the worker pool settings. Everything except HTTPAddr
+// and the claim scheduling settings can be applied on reload without
+// restarting.
+type WorkerConfig struct {
+	PoolSize     int           `yaml:"pool_size" toml:"pool_size" env:"WORKER_POOL_SIZE"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"WORKER_POLL_INTERVAL"`
+	ProcessDelay time.Duration `yaml:"process_delay" toml:"process_delay" env:"WORKER_PROCESS_DELAY"`
+	// TypeLimits caps how many events of a type one worker pool process
+	// handles at once. Types not listed are only bounded by PoolSize.
+	TypeLimits map[string]int `yaml:"type_limits" toml:"type_limits" env:"WORKER_TYPE_LIMITS"`
+	// HTTPAddr is where the worker pool serves /metrics, /healthz and
+	// /readyz. Empty disables it.
+	HTTPAddr string `yaml:"http_addr" toml:"http_addr" env:"WORKER_HTTP_ADDR"`
+	// HeartbeatInterval is how often a worker pool process refreshes its row
+	// in the workers table, and the lease on each event it is processing.
+	// The admin API reports a process as dead once its heartbeat is older
+	// than HeartbeatTimeout, and an event whose lease or process has gone
+	// that long without renewal is returned to the queue.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval" toml:"heartbeat_interval" env:"WORKER_HEARTBEAT_INTERVAL"`
+	HeartbeatTimeout  time.Duration `yaml:"heartbeat_timeout" toml:"heartbeat_timeout" env:"WORKER_HEARTBEAT_TIMEOUT"`
+	// ClaimStrategy decides whose event a worker claims next: fifo takes the
+	// oldest event overall, round_robin gives each partition of FairBy a turn
+	// in order, and drr (deficit round-robin) gives each partition as many
+	// claims per round as its FairWeights entry, 1 when unlisted.
+	ClaimStrategy string         `yaml:"claim_strategy" toml:"claim_strategy" env:"WORKER_CLAIM_STRATEGY"`
+	FairBy        string         `yaml:"fair_by" toml:"fair_by" env:"WORKER_FAIR_BY"`
+	FairWeights   map[string]int `yaml:"fair_weights" toml:"fair_weights" env:"WORKER_FAIR_WEIGHTS"`
+	// HandlerTimeout bounds each processing attempt; HandlerTimeouts
+	// overrides it per event type. An attempt past its deadline fails with
+	// reason timeout and is retried like any other failure.
+	HandlerTimeout  time.Duration            `yaml:"handler_timeout" toml:"handler_timeout" env:"WORKER_HANDLER_TIMEOUT"`
+	HandlerTimeouts map[string]time.Duration `yaml:"handler_timeouts" toml:"handler_timeouts" env:"WORKER_HANDLER_TIMEOUTS"`
+	// Chaos injects faults into event processing for testing. Never enable
+	// it in production.
+	Chaos ChaosConfig `yaml:"chaos" toml:"chaos"`
+}
+
+// TimeoutFor is the handler timeout for an event type.
+func (c WorkerConfig) TimeoutFor(eventType string) time.Duration {
+	if timeout, ok := c.HandlerTimeouts[eventType]; ok {
+		return timeout
+	}
+	return c.HandlerTimeout
+}
+
+// Chaos latency distributions.
+const (
+	ChaosLatencyUniform     = "uniform"
+	ChaosLatencyExponential = "exponential"
+)
+
+// ChaosConfig makes the worker pool fail in controlled ways, to exercise
+// retries and failure handling. Nothing is injected unless Enabled. Default
+// applies to every event type and Types replaces it for individual types;
+// Types can only be set in a config file. With Markers, an event whose ID
+// starts with evt_chaos-<fault>- gets that fault (error, panic, hang or
+// latency) whatever the rates say, which is how loadsim forces outcomes.
+type ChaosConfig struct {
+	Enabled bool                 `yaml:"enabled" toml:"enabled" env:"WORKER_CHAOS_ENABLED"`
+	Markers bool                 `yaml:"markers" toml:"markers" env:"WORKER_CHAOS_MARKERS"`
+	Default ChaosRule            `yaml:"default" toml:"default"`
+	Types   map[string]ChaosRule `yaml:"types" toml:"types"`
+}
+
+// ChaosRule is the faults injected for one event type. Each rate is a
+// probability per attempt. An attempt may be delayed first, and then hangs
+// until its handler timeout, panics or fails, in that order of checking.
+type ChaosRule struct {
+	ErrorRate float64 `yaml:"error_rate" toml:"error_rate" env:"WORKER_CHAOS_ERROR_RATE"`
+	PanicRate float64 `yaml:"panic_rate" toml:"panic_rate" env:"WORKER_CHAOS_PANIC_RATE"`
+	HangRate  float64 `yaml:"hang_rate" toml:"hang_rate" env:"WORKER_CHAOS_HANG_RATE"`
+	// LatencyRate is the share of attempts delayed. A uniform delay is
+	// drawn between LatencyMin and LatencyMax; an exponential one adds a
+	// draw with mean LatencyMean to LatencyMin, capped at LatencyMax when
+	// that is set. An empty distribution is uniform.
+	LatencyRate         float64       `yaml:"latency_rate" toml:"latency_rate" env:"WORKER_CHAOS_LATENCY_RATE"`
+	LatencyDistribution string        `yaml:"latency_distribution" toml:"latency_distribution" env:"WORKER_CHAOS_LATENCY_DISTRIBUTION"`
+	LatencyMin          time.Duration `yaml:"latency_min" toml:"latency_min" env:"WORKER_CHAOS_LATENCY_MIN"`
+	LatencyMax          time.Duration `yaml:"latency_max" toml:"latency_max" env:"WORKER_CHAOS_LATENCY_MAX"`
+	LatencyMean         time.Duration `yaml:"latency_mean" toml:"latency_mean" env:"WORKER_CHAOS_LATENCY_MEAN"`
+}
+
+// Rule is the chaos rule for an event type.
+func (c ChaosConfig) Rule(eventType string) ChaosRule {
+	if rule, ok := c.Types[eventType]; ok {
+		return rule
+	}
+	return c.Default
+}
+
+type DatabaseConfig struct {
+	URL               string        `yaml:"url" toml:"url" env:"DB_URL"`
+	MaxConns          int32         `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns          int32         `yaml:"min_conns" toml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	// StatementTimeout is set as the session statement_timeout on every
+	// pooled connection. Zero leaves the server default.
+	StatementTimeout time.Duration `yaml:"statement_timeout" toml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	// ConnectTimeout bounds each startup connection attempt; failed attempts
+	// are retried up to ConnectAttempts times with exponential backoff.
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	ConnectAttempts   int           `yaml:"connect_attempts" toml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	ConnectBackoff    time.Duration `yaml:"connect_backoff" toml:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	ConnectMaxBackoff time.Duration `yaml:"connect_max_backoff" toml:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
+	// AutoMigrate applies pending migrations on startup, serialised across
+	// replicas by an advisory lock. Off by default; use `admin migrate up`.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"DB_AUTO_MIGRATE"`
+	// StatsInterval is how often pool statistics are logged. Zero disables
+	// the log line; the metrics are exported regardless.
+	StatsInterval time.Duration `yaml:"stats_interval" toml:"stats_interval" env:"DB_STATS_INTERVAL"`
+}
+
+// BackpressureConfig makes the API server answer 503 while the backlog of
+// received events is past either threshold. A zero threshold is ignored;
+// with every threshold zero the check is off.
+type BackpressureConfig struct {
+	MaxQueueDepth int64         `yaml:"max_queue_depth" toml:"max_queue_depth" env:"INGEST_MAX_QUEUE_DEPTH"`
+	MaxOldestAge  time.Duration `yaml:"max_oldest_age" toml:"max_oldest_age" env:"INGEST_MAX_OLDEST_AGE"`
+	// CheckInterval is how often the queue stats are re-read; requests use
+	// the cached result in between.
+	CheckInterval time.Duration `yaml:"check_interval" toml:"check_interval" env:"INGEST_CHECK_INTERVAL"`
+	RetryAfter    time.Duration `yaml:"retry_after" toml:"retry_after" env:"INGEST_RETRY_AFTER"`
+	// PriorityTypes are accepted even under backpressure.
+	PriorityTypes []string `yaml:"priority_types" toml:"priority_types" env:"INGEST_PRIORITY_TYPES"`
+	// TenantMaxQueueDepth caps each tenant's share of the backlog; a tenant
+	// past it gets 429 while the others are still accepted. TenantQuotas
+	// overrides it for individual tenants. Zero is no cap.
+	TenantMaxQueueDepth int64          `yaml:"tenant_max_queue_depth" toml:"tenant_max_queue_depth" env:"INGEST_TENANT_MAX_QUEUE_DEPTH"`
+	TenantQuotas        map[string]int `yaml:"tenant_quotas" toml:"tenant_quotas" env:"INGEST_TENANT_QUOTAS"`
+}
+
+// RetryConfig controls how failed events are rescheduled before being left
+// in the failed state.
+type RetryConfig struct {
+	MaxAttempts    int32         `yaml:"max_attempts" toml:"max_attempts" env:"RETRY_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff" env:"RETRY_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff" env:"RETRY_MAX_BACKOFF"`
+}
+
+type SecurityConfig struct {
+	WebhookSigningSecret string `yaml:"webhook_signing_secret" toml:"webhook_signing_secret" env:"WEBHOOK_SIGNING_SECRET"`
+	// RequireAPIKeys rejects requests without an X-API-Key. When false,
+	// anonymous requests are served but presented keys are still checked.
+	RequireAPIKeys bool `yaml:"require_api_keys" toml:"require_api_keys" env:"REQUIRE_API_KEYS"`
+	// CORSAllowOrigins lists browser origins allowed to call the API. Empty
+	// sends no CORS headers.
+	CORSAllowOrigins []string `yaml:"cors_allow_origins" toml:"cors_allow_origins" env:"CORS_ALLOW_ORIGINS"`
 }
 
+// ValidationConfig bounds the payment webhook payloads accepted at ingest.
+type ValidationConfig struct {
+	MinAmount      string        `yaml:"min_amount" toml:"min_amount" env:"WEBHOOK_MIN_AMOUNT"`
+	MaxAmount      string        `yaml:"max_amount" toml:"max_amount" env:"WEBHOOK_MAX_AMOUNT"`
+	AllowedTypes   []string      `yaml:"allowed_types" toml:"allowed_types" env:"WEBHOOK_ALLOWED_TYPES"`
+	MaxFutureSkew  time.Duration `yaml:"max_future_skew" toml:"max_future_skew" env:"WEBHOOK_MAX_FUTURE_SKEW"`
+	EventIDPattern string        `yaml:"event_id_pattern" toml:"event_id_pattern" env:"WEBHOOK_EVENT_ID_PATTERN"`
+}
+
+func Default() Config {
+	return Config{
+		Log: LogConfig{
+			Level: "info",
+		},
+		Server: ServerConfig{
+			Port:            "3333",
+			RequestTimeout:  5 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			MaxBodyBytes:    1 << 20,
+			MetricsAddr:     ":9091",
+		},
+		RateLimit: RateLimitConfig{
+			Burst: 20,
+			KeyBy: RateLimitByIP,
+		},
+		Backpressure: BackpressureConfig{
+			CheckInterval: 5 * time.Second,
+			RetryAfter:    30 * time.Second,
+		},
+		Worker: WorkerConfig{
+			PoolSize:          5,
+			PollInterval:      2 * time.Second,
+			ProcessDelay:      100 * time.Millisecond,
+			HTTPAddr:          ":9090",
+			HeartbeatInterval: 10 * time.Second,
+			HeartbeatTimeout:  30 * time.Second,
+			HandlerTimeout:    30 * time.Second,
+			ClaimStrategy:     ClaimFIFO,
+			FairBy:            FairByTenant,
+		},
+		Database: DatabaseConfig{
+			MaxConns:          10,
+			MinConns:          0,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+			StatementTimeout:  30 * time.Second,
+			ConnectTimeout:    5 * time.Second,
+			ConnectAttempts:   10,
+			ConnectBackoff:    500 * time.Millisecond,
+			ConnectMaxBackoff: 15 * time.Second,
+			StatsInterval:     time.Minute,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+			MaxBackoff:     5 * time.Minute,
+		},
+		Security: SecurityConfig{
+			RequireAPIKeys: true,
+		},
+		Validation: DefaultValidationConfig(),
+	}
+}
+
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		MinAmount:      "0",
+		MaxAmount:      "1000000000",
+		AllowedTypes:   []string{"payment.completed", "payment.pending", "payment.failed", "payment.refunded"},
+		MaxFutureSkew:  5 * time.Minute,
+		EventIDPattern: `^evt_[A-Za-z0-9_-]{1,64}$`,
+	}
+}
+
+// LoadConfig loads the file named by CONFIG_FILE, if any, then applies
+// environment overrides.
 func LoadConfig() (Config, error) {
-	var config Config
+	return Load(os.Getenv("CONFIG_FILE"))
+}
+
+// Load builds the effective configuration: defaults, then the YAML or TOML
+// file at path (when non-empty), then environment variables. Every invalid
+// value is reported in the returned error rather than just the first.
+func Load(path string) (Config, error) {
+	config := Default()
 
 	if os.Getenv("ENV") != "production" {
-		if err := godotenv.Load(); err != nil {
+		if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 			return config, fmt.Errorf("error loading .env file: %w", err)
 		}
 	}
 
-	config.Port = mustGetEnv("PORT")
+	if path != "" {
+		if err := loadFile(path, &config); err != nil {
+			return config, err
+		}
+	}
+
+	errs := applyEnv(&config)
+	if err := config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 
-	config.DatabaseURL = mustGetEnv("DB_URL")
+	return config, errors.Join(errs...)
+}
 
-	if _, err := strconv.Atoi(config.Port); err != nil {
-		return config, fmt.Errorf("invalid port number: %w", err)
+func loadFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
 	}
 
-	return config, nil
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), config)
+		if err != nil {
+			return fmt.Errorf("parse config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (want .yaml, .yml or .toml)", ext)
+	}
+
+	return nil
+}
+
+// Redacted returns a copy that is safe to print or log.
+func (c Config) Redacted() Config {
+	c.Database.URL = redactURL(c.Database.URL)
+	if c.Security.WebhookSigningSecret != "" {
+		c.Security.WebhookSigningSecret = redacted
+	}
+	return c
+}
+
+// dsnSecret matches a password setting in a keyword/value connection
+// string, with the value quoted or bare.
+var dsnSecret = regexp.MustCompile(`\b((?:ssl)?password)\s*=\s*(?:'(?:\\.|[^'])*'|\S+)`)
+
+// redactURL masks the passwords in a database URL, whether it is a
+// postgres:// URL, with the password in its userinfo or a password query
+// parameter, or a keyword/value DSN. A URL it cannot parse is redacted
+// entirely.
+func redactURL(raw string) string {
+	if !strings.HasPrefix(raw, "postgres://") && !strings.HasPrefix(raw, "postgresql://") {
+		return dsnSecret.ReplaceAllString(raw, "${1}=xxxxx")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+	}
+	query := u.Query()
+	for _, key := range []string{"password", "sslpassword"} {
+		if query.Has(key) {
+			query.Set(key, "xxxxx")
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
 }
 
-func mustGetEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		panic(fmt.Sprintf("required environment variable %s is not set", key))
+// Print writes the redacted configuration as YAML.
+func Print(w io.Writer, c Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
 	}
-	return value
+	return enc.Close()
 }
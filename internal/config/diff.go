@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is one setting that differs between two configurations, keyed by
+// its dotted file path (for example "worker.pool_size").
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// Diff lists the settings that differ between old and new. Secrets are
+// compared on their redacted form, so their values never appear.
+func Diff(old, new Config) []Change {
+	return diffValues("", reflect.ValueOf(old.Redacted()), reflect.ValueOf(new.Redacted()))
+}
+
+func diffValues(prefix string, a, b reflect.Value) []Change {
+	var changes []Change
+	t := a.Type()
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct && fa.Type() != durationType {
+			changes = append(changes, diffValues(path, fa, fb)...)
+			continue
+		}
+
+		if !equalValues(fa, fb) {
+			changes = append(changes, Change{
+				Path: path,
+				Old:  fmt.Sprint(fa.Interface()),
+				New:  fmt.Sprint(fb.Interface()),
+			})
+		}
+	}
+
+	return changes
+}
+
+// equalValues treats nil and empty maps or slices as equal, since a file
+// that spells out an empty section should not count as a change.
+func equalValues(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Map, reflect.Slice:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides every field tagged `env` whose variable is set. Values
+// that fail to parse are returned as errors instead of being ignored.
+func applyEnv(config *Config) []error {
+	return applyEnvValue(reflect.ValueOf(config).Elem())
+}
+
+func applyEnvValue(v reflect.Value) []error {
+	var errs []error
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			errs = append(errs, applyEnvValue(field)...)
+			continue
+		}
+
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(key)
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := setFromString(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	return errs
+}
+
+func setFromString(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", field.Type())
+		}
+		var m any
+		var err error
+		switch {
+		case field.Type().Elem() == durationType:
+			m, err = parseDurationMap(raw)
+		case field.Type().Elem().Kind() == reflect.Int:
+			m, err = parseIntMap(raw)
+		default:
+			return fmt.Errorf("unsupported map type %s", field.Type())
+		}
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseIntMap parses "a=1,b=2" into a map.
+func parseIntMap(s string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, pair := range splitList(s) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, want key=value", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer in %q", pair)
+		}
+		out[strings.TrimSpace(key)] = n
+	}
+	return out, nil
+}
+
+// parseDurationMap parses "a=1s,b=2m" into a map.
+func parseDurationMap(s string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	for _, pair := range splitList(s) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, want key=value", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration in %q", pair)
+		}
+		out[strings.TrimSpace(key)] = d
+	}
+	return out, nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Validate checks every section and joins all problems into one error.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	_, err := zerolog.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: unknown level %q", c.Log.Level)
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port: invalid port number %q", c.Server.Port)
+	check(c.Server.RequestTimeout >= 0, "server.request_timeout: must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay: must not be negative")
+	check(c.Server.MaxBodyBytes >= 1, "server.max_body_bytes: must be at least 1")
+	for _, cidr := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(cidr)
+		check(err == nil, "server.trusted_proxies: invalid CIDR %q", cidr)
+	}
+
+	check(c.RateLimit.RPS >= 0, "rate_limit.rps: must not be negative")
+	check(c.RateLimit.Burst >= 1, "rate_limit.burst: must be at least 1")
+	switch c.RateLimit.KeyBy {
+	case RateLimitByIP, RateLimitByProvider, RateLimitByAPIKey:
+	default:
+		errs = append(errs, fmt.Errorf("rate_limit.key_by: must be one of %s, %s, %s",
+			RateLimitByIP, RateLimitByProvider, RateLimitByAPIKey))
+	}
+
+	check(c.Backpressure.MaxQueueDepth >= 0, "backpressure.max_queue_depth: must not be negative")
+	check(c.Backpressure.MaxOldestAge >= 0, "backpressure.max_oldest_age: must not be negative")
+	check(c.Backpressure.CheckInterval > 0, "backpressure.check_interval: must be positive")
+	check(c.Backpressure.RetryAfter >= time.Second, "backpressure.retry_after: must be at least 1s")
+	check(c.Backpressure.TenantMaxQueueDepth >= 0, "backpressure.tenant_max_queue_depth: must not be negative")
+	for tenant, quota := range c.Backpressure.TenantQuotas {
+		check(quota >= 1, "backpressure.tenant_quotas.%s: must be at least 1", tenant)
+	}
+
+	check(c.Worker.PoolSize >= 1, "worker.pool_size: must be at least 1")
+	check(c.Worker.PollInterval > 0, "worker.poll_interval: must be positive")
+	check(c.Worker.ProcessDelay >= 0, "worker.process_delay: must not be negative")
+	check(c.Worker.HeartbeatInterval > 0, "worker.heartbeat_interval: must be positive")
+	check(c.Worker.HeartbeatTimeout > c.Worker.HeartbeatInterval && c.Worker.HeartbeatTimeout > c.Worker.PollInterval,
+		"worker.heartbeat_timeout: must be greater than worker.heartbeat_interval and worker.poll_interval")
+	check(c.Worker.HandlerTimeout > 0, "worker.handler_timeout: must be positive")
+	for eventType, timeout := range c.Worker.HandlerTimeouts {
+		check(timeout > 0, "worker.handler_timeouts.%s: must be positive", eventType)
+	}
+	for eventType, limit := range c.Worker.TypeLimits {
+		check(limit >= 1, "worker.type_limits.%s: must be at least 1", eventType)
+	}
+	switch c.Worker.ClaimStrategy {
+	case ClaimFIFO, ClaimRoundRobin, ClaimDRR:
+	default:
+		errs = append(errs, fmt.Errorf("worker.claim_strategy: must be one of %s, %s, %s",
+			ClaimFIFO, ClaimRoundRobin, ClaimDRR))
+	}
+	check(c.Worker.FairBy == FairByTenant || c.Worker.FairBy == FairByType,
+		"worker.fair_by: must be %s or %s", FairByTenant, FairByType)
+	for partition, weight := range c.Worker.FairWeights {
+		check(weight >= 1, "worker.fair_weights.%s: must be at least 1", partition)
+	}
+	errs = append(errs, c.Worker.Chaos.Default.validate("worker.chaos.default")...)
+	for eventType, rule := range c.Worker.Chaos.Types {
+		errs = append(errs, rule.validate("worker.chaos.types."+eventType)...)
+	}
+
+	check(c.Database.URL != "", "database.url: is required")
+	check(c.Database.MaxConns >= 1, "database.max_conns: must be at least 1")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns: must be between 0 and database.max_conns")
+	check(c.Database.MaxConnLifetime >= 0, "database.max_conn_lifetime: must not be negative")
+	check(c.Database.MaxConnIdleTime >= 0, "database.max_conn_idle_time: must not be negative")
+	check(c.Database.HealthCheckPeriod >= 0, "database.health_check_period: must not be negative")
+	check(c.Database.StatementTimeout >= 0, "database.statement_timeout: must not be negative")
+	check(c.Database.ConnectTimeout > 0, "database.connect_timeout: must be positive")
+	check(c.Database.ConnectAttempts >= 1, "database.connect_attempts: must be at least 1")
+	check(c.Database.ConnectBackoff > 0, "database.connect_backoff: must be positive")
+	check(c.Database.ConnectMaxBackoff >= c.Database.ConnectBackoff,
+		"database.connect_max_backoff: must not be less than database.connect_backoff")
+	check(c.Database.StatsInterval >= 0, "database.stats_interval: must not be negative")
+
+	check(c.Retry.MaxAttempts >= 1, "retry.max_attempts: must be at least 1")
+	check(c.Retry.InitialBackoff > 0, "retry.initial_backoff: must be positive")
+	check(c.Retry.MaxBackoff >= c.Retry.InitialBackoff, "retry.max_backoff: must not be less than retry.initial_backoff")
+
+	minAmount, minOK := new(big.Rat).SetString(c.Validation.MinAmount)
+	check(minOK && minAmount.Sign() >= 0, "validation.min_amount: must be a non-negative decimal")
+	maxAmount, maxOK := new(big.Rat).SetString(c.Validation.MaxAmount)
+	check(maxOK, "validation.max_amount: must be a decimal")
+	if minOK && maxOK {
+		check(maxAmount.Cmp(minAmount) >= 0, "validation.max_amount: must not be less than validation.min_amount")
+	}
+	check(len(c.Validation.AllowedTypes) > 0, "validation.allowed_types: at least one type is required")
+	check(c.Validation.MaxFutureSkew >= 0, "validation.max_future_skew: must not be negative")
+	_, err = regexp.Compile(c.Validation.EventIDPattern)
+	check(err == nil, "validation.event_id_pattern: %v", err)
+
+	return errors.Join(errs...)
+}
+
+func (r ChaosRule) validate(path string) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s.%s", path, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(r.ErrorRate >= 0 && r.ErrorRate <= 1, "error_rate: must be between 0 and 1")
+	check(r.PanicRate >= 0 && r.PanicRate <= 1, "panic_rate: must be between 0 and 1")
+	check(r.HangRate >= 0 && r.HangRate <= 1, "hang_rate: must be between 0 and 1")
+	check(r.LatencyRate >= 0 && r.LatencyRate <= 1, "latency_rate: must be between 0 and 1")
+	check(r.ErrorRate+r.PanicRate+r.HangRate <= 1, "error_rate, panic_rate and hang_rate: must add up to at most 1")
+	check(r.LatencyMin >= 0, "latency_min: must not be negative")
+	check(r.LatencyMax >= 0, "latency_max: must not be negative")
+	switch r.LatencyDistribution {
+	case "", ChaosLatencyUniform:
+		check(r.LatencyMax >= r.LatencyMin, "latency_max: must not be less than latency_min")
+		check(r.LatencyRate == 0 || r.LatencyMax > 0, "latency_max: must be positive when latency_rate is set")
+	case ChaosLatencyExponential:
+		check(r.LatencyMax == 0 || r.LatencyMax >= r.LatencyMin, "latency_max: must not be less than latency_min")
+		check(r.LatencyRate == 0 || r.LatencyMean > 0, "latency_mean: must be positive when latency_rate is set")
+	default:
+		errs = append(errs, fmt.Errorf("%s.latency_distribution: must be %s or %s", path, ChaosLatencyUniform, ChaosLatencyExponential))
+	}
+	return errs
+}
@@ -0,0 +1,821 @@
+// Package dbtest provides an in-memory db.Store for unit tests that need the
+// queue to behave like Postgres without running one.
+package dbtest
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Postgres error codes the store reports for constraint violations, so
+// callers can match them the same way as errors from the real database.
+const (
+	UniqueViolation     = "23505"
+	ForeignKeyViolation = "23503"
+	CheckViolation      = "23514"
+)
+
+// Store is an in-memory db.Store that follows the queries in
+// internal/db/sqlc/queries: claims take the oldest due, unpaused event and
+// move it to processing, status updates return pgx.ErrNoRows for unknown
+// IDs, and event IDs are unique per tenant. Every call runs under one lock,
+// so concurrent claims never return the same event, as FOR UPDATE SKIP
+// LOCKED guarantees in Postgres. Constraint violations come back as
+// *pgconn.PgError with the database's codes and constraint names.
+//
+// The zero value is not usable; create one with NewStore.
+type Store struct {
+	// Now is the store's clock, used for timestamps and to decide which
+	// events are due. Set it before use; it defaults to time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	seq      int64
+	events   []*event
+	attempts []sqlc.WebhookAttempt
+	apiKeys  []sqlc.ApiKey
+	controls map[string]sqlc.QueueControl
+	workers  map[uuid.UUID]sqlc.Worker
+}
+
+// event is a row of webhook_events with its insertion order, which breaks
+// ties between events received at the same instant.
+type event struct {
+	sqlc.WebhookEvent
+	seq int64
+}
+
+var _ db.Store = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		Now:      time.Now,
+		controls: make(map[string]sqlc.QueueControl),
+		workers:  make(map[uuid.UUID]sqlc.Worker),
+	}
+}
+
+func (s *Store) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Events returns every event in the order it was stored.
+func (s *Store) Events() []sqlc.WebhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]sqlc.WebhookEvent, len(s.events))
+	for i, e := range s.events {
+		events[i] = cloneEvent(e.WebhookEvent)
+	}
+	return events
+}
+
+// PutEvent stores e as given, replacing any event with the same ID, so a test
+// can start from any state. A zero ID, status or timestamp is filled in as
+// the column default would be; constraints are not checked.
+func (s *Store) PutEvent(e sqlc.WebhookEvent) sqlc.WebhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := timestamptz(s.now())
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.Status == "" {
+		e.Status = db.ReceivedStatus
+	}
+	if !e.ReceivedAt.Valid {
+		e.ReceivedAt = now
+	}
+	if !e.UpdatedAt.Valid {
+		e.UpdatedAt = now
+	}
+	if !e.NextAttemptAt.Valid {
+		e.NextAttemptAt = now
+	}
+	e = cloneEvent(e)
+
+	if existing := s.event(e.ID); existing != nil {
+		existing.WebhookEvent = e
+	} else {
+		s.seq++
+		s.events = append(s.events, &event{WebhookEvent: e, seq: s.seq})
+	}
+	return cloneEvent(e)
+}
+
+// PutWorker stores w as given, replacing any worker with the same ID. Zero
+// timestamps are filled in with the store's clock.
+func (s *Store) PutWorker(w sqlc.Worker) sqlc.Worker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	if !w.StartedAt.Valid {
+		w.StartedAt = timestamptz(s.now())
+	}
+	if !w.HeartbeatAt.Valid {
+		w.HeartbeatAt = w.StartedAt
+	}
+	s.workers[w.ID] = w
+	return w
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// SchemaVersion reports the embedded migrations as fully applied.
+func (s *Store) SchemaVersion(ctx context.Context) (uint, bool, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, false, err
+	}
+	version, err := db.ExpectedVersion()
+	if err != nil {
+		return 0, false, false, err
+	}
+	return version, false, true, nil
+}
+
+// Webhook events
+
+func (s *Store) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.events {
+		if e.TenantID == arg.TenantID && e.EventID == arg.EventID {
+			return sqlc.WebhookEvent{}, violation(UniqueViolation, "webhook_events_tenant_event_id_key",
+				"duplicate key value violates unique constraint")
+		}
+	}
+
+	now := timestamptz(s.now())
+	s.seq++
+	e := &event{
+		WebhookEvent: cloneEvent(sqlc.WebhookEvent{
+			ID:            uuid.New(),
+			EventID:       arg.EventID,
+			Type:          arg.Type,
+			Payload:       arg.Payload,
+			Status:        db.ReceivedStatus,
+			ReceivedAt:    now,
+			UpdatedAt:     now,
+			NextAttemptAt: now,
+			TenantID:      arg.TenantID,
+		}),
+		seq: s.seq,
+	}
+	s.events = append(s.events, e)
+	return cloneEvent(e.WebhookEvent), nil
+}
+
+// ClaimNextWebhook claims the oldest claimable event in the requested
+// partition, or returns pgx.ErrNoRows.
+func (s *Store) ClaimNextWebhook(ctx context.Context, arg sqlc.ClaimNextWebhookParams) (sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var next *event
+	for _, e := range s.events {
+		if !s.claimable(e, now, arg.ExcludedTypes) || partitionOf(e, arg.PartitionBy, arg.Partition) != arg.Partition {
+			continue
+		}
+		if next == nil || olderThan(e, next) {
+			next = e
+		}
+	}
+	if next == nil {
+		return sqlc.WebhookEvent{}, pgx.ErrNoRows
+	}
+	if _, ok := s.workers[arg.ClaimedBy]; !ok {
+		return sqlc.WebhookEvent{}, violation(ForeignKeyViolation, "webhook_events_claimed_by_fkey",
+			"insert or update on table \"webhook_events\" violates foreign key constraint")
+	}
+
+	next.Status = db.ProcessingStatus
+	next.Attempts++
+	next.ClaimedBy = pgtype.UUID{Bytes: arg.ClaimedBy, Valid: true}
+	next.ClaimedAt = timestamp(now)
+	next.UpdatedAt = timestamptz(now)
+	return cloneEvent(next.WebhookEvent), nil
+}
+
+func (s *Store) ListClaimablePartitions(ctx context.Context, arg sqlc.ListClaimablePartitionsParams) ([]sqlc.ListClaimablePartitionsRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	depths := make(map[string]int64)
+	for _, e := range s.events {
+		if s.claimable(e, now, arg.ExcludedTypes) {
+			depths[partitionKey(e, arg.PartitionBy)]++
+		}
+	}
+
+	rows := make([]sqlc.ListClaimablePartitionsRow, 0, len(depths))
+	for partition, depth := range depths {
+		rows = append(rows, sqlc.ListClaimablePartitionsRow{Partition: partition, Depth: depth})
+	}
+	slices.SortFunc(rows, func(a, b sqlc.ListClaimablePartitionsRow) int { return cmp.Compare(a.Partition, b.Partition) })
+	return rows, nil
+}
+
+func (s *Store) MarkWebhookDone(ctx context.Context, arg sqlc.MarkWebhookDoneParams) (sqlc.WebhookEvent, error) {
+	return s.settle(ctx, arg.ID, arg.ClaimedBy, func(e *event, now time.Time) {
+		e.Status = db.DoneStatus
+		e.ProcessedAt = timestamp(now)
+	})
+}
+
+func (s *Store) MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error) {
+	return s.settle(ctx, arg.ID, arg.ClaimedBy, func(e *event, now time.Time) {
+		e.Status = db.FailedStatus
+		e.LastError = clonePtr(arg.LastError)
+	})
+}
+
+func (s *Store) RetryWebhook(ctx context.Context, arg sqlc.RetryWebhookParams) (sqlc.WebhookEvent, error) {
+	return s.settle(ctx, arg.ID, arg.ClaimedBy, func(e *event, now time.Time) {
+		e.Status = db.ReceivedStatus
+		e.LastError = clonePtr(arg.LastError)
+		e.NextAttemptAt = arg.NextAttemptAt
+	})
+}
+
+// settle applies fn to a processing event claimed by claimedBy, mirroring
+// the claim fence on the settle queries.
+func (s *Store) settle(ctx context.Context, id, claimedBy uuid.UUID, fn func(e *event, now time.Time)) (sqlc.WebhookEvent, error) {
+	return s.update(ctx, id, func(e *event, now time.Time) error {
+		if e.Status != db.ProcessingStatus || !e.ClaimedBy.Valid || e.ClaimedBy.Bytes != claimedBy {
+			return pgx.ErrNoRows
+		}
+		fn(e, now)
+		return nil
+	})
+}
+
+func (s *Store) ReleaseWebhook(ctx context.Context, arg sqlc.ReleaseWebhookParams) (sqlc.WebhookEvent, error) {
+	return s.update(ctx, arg.ID, func(e *event, now time.Time) error {
+		if e.Status != db.ProcessingStatus || !e.ClaimedBy.Valid || e.ClaimedBy.Bytes != arg.ClaimedBy {
+			return pgx.ErrNoRows
+		}
+		if e.Attempts == 0 {
+			return violation(CheckViolation, "webhook_events_attempts_check",
+				"new row for relation \"webhook_events\" violates check constraint")
+		}
+		e.Status = db.ReceivedStatus
+		e.Attempts--
+		e.ClaimedBy = pgtype.UUID{}
+		e.ClaimedAt = pgtype.Timestamp{}
+		return nil
+	})
+}
+
+func (s *Store) RenewWebhookLease(ctx context.Context, arg sqlc.RenewWebhookLeaseParams) (sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.event(arg.ID)
+	if e == nil || e.Status != db.ProcessingStatus || !e.ClaimedBy.Valid || e.ClaimedBy.Bytes != arg.ClaimedBy {
+		return sqlc.WebhookEvent{}, pgx.ErrNoRows
+	}
+	e.ClaimedAt = timestamp(s.now())
+	return cloneEvent(e.WebhookEvent), nil
+}
+
+// ReclaimExpiredWebhooks returns to the queue the processing events whose
+// lease is older than leaseSeconds or whose worker is stopped, stale or
+// gone.
+func (s *Store) ReclaimExpiredWebhooks(ctx context.Context, leaseSeconds float64) ([]sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cutoff := now.Add(-time.Duration(leaseSeconds * float64(time.Second)))
+	lastError := "claim expired before the attempt was settled"
+	reclaimed := []sqlc.WebhookEvent{}
+	for _, e := range s.events {
+		if e.Status != db.ProcessingStatus || !s.claimExpired(e, cutoff) {
+			continue
+		}
+		e.Status = db.ReceivedStatus
+		e.ClaimedBy = pgtype.UUID{}
+		e.ClaimedAt = pgtype.Timestamp{}
+		e.LastError = &lastError
+		e.NextAttemptAt = timestamptz(now)
+		e.UpdatedAt = timestamptz(now)
+		reclaimed = append(reclaimed, cloneEvent(e.WebhookEvent))
+	}
+	return reclaimed, nil
+}
+
+func (s *Store) claimExpired(e *event, cutoff time.Time) bool {
+	if !e.ClaimedBy.Valid || e.ClaimedAt.Time.Before(cutoff) {
+		return true
+	}
+	w, ok := s.workers[e.ClaimedBy.Bytes]
+	return !ok || w.StoppedAt.Valid || w.HeartbeatAt.Time.Before(cutoff)
+}
+
+func (s *Store) GetWebhookByEventID(ctx context.Context, arg sqlc.GetWebhookByEventIDParams) (sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.events {
+		if e.TenantID == arg.TenantID && e.EventID == arg.EventID {
+			return cloneEvent(e.WebhookEvent), nil
+		}
+	}
+	return sqlc.WebhookEvent{}, pgx.ErrNoRows
+}
+
+func (s *Store) GetQueueStats(ctx context.Context) (sqlc.GetQueueStatsRow, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.GetQueueStatsRow{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var stats sqlc.GetQueueStatsRow
+	var oldest time.Time
+	for _, e := range s.events {
+		if !s.backlogged(e, now) {
+			continue
+		}
+		stats.Depth++
+		if oldest.IsZero() || e.ReceivedAt.Time.Before(oldest) {
+			oldest = e.ReceivedAt.Time
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestAgeSeconds = now.Sub(oldest).Seconds()
+	}
+	return stats, nil
+}
+
+func (s *Store) GetTenantQueueStats(ctx context.Context) ([]sqlc.GetTenantQueueStatsRow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	depths := make(map[string]int64)
+	for _, e := range s.events {
+		if s.backlogged(e, now) {
+			depths[e.TenantID]++
+		}
+	}
+
+	rows := make([]sqlc.GetTenantQueueStatsRow, 0, len(depths))
+	for tenant, depth := range depths {
+		rows = append(rows, sqlc.GetTenantQueueStatsRow{TenantID: tenant, Depth: depth})
+	}
+	slices.SortFunc(rows, func(a, b sqlc.GetTenantQueueStatsRow) int { return cmp.Compare(a.TenantID, b.TenantID) })
+	return rows, nil
+}
+
+func (s *Store) ListProcessingWebhooks(ctx context.Context) ([]sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var processing []sqlc.WebhookEvent
+	for _, e := range s.events {
+		if e.Status == db.ProcessingStatus && e.ClaimedBy.Valid {
+			processing = append(processing, cloneEvent(e.WebhookEvent))
+		}
+	}
+	slices.SortStableFunc(processing, func(a, b sqlc.WebhookEvent) int { return a.ClaimedAt.Time.Compare(b.ClaimedAt.Time) })
+	return processing, nil
+}
+
+// update applies fn to the event with the given ID and stamps updated_at,
+// leaving the event untouched when fn fails.
+func (s *Store) update(ctx context.Context, id uuid.UUID, fn func(e *event, now time.Time) error) (sqlc.WebhookEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.event(id)
+	if e == nil {
+		return sqlc.WebhookEvent{}, pgx.ErrNoRows
+	}
+	now := s.now()
+	updated := &event{WebhookEvent: cloneEvent(e.WebhookEvent), seq: e.seq}
+	if err := fn(updated, now); err != nil {
+		return sqlc.WebhookEvent{}, err
+	}
+	updated.UpdatedAt = timestamptz(now)
+	*e = *updated
+	return cloneEvent(e.WebhookEvent), nil
+}
+
+func (s *Store) event(id uuid.UUID) *event {
+	for _, e := range s.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// claimable mirrors the WHERE clause shared by ClaimNextWebhook and
+// ListClaimablePartitions.
+func (s *Store) claimable(e *event, now time.Time, excludedTypes []string) bool {
+	return e.Status == db.ReceivedStatus &&
+		!e.NextAttemptAt.Time.After(now) &&
+		!slices.Contains(excludedTypes, typeOf(e)) &&
+		!s.paused(typeOf(e))
+}
+
+// backlogged mirrors the WHERE clause of the queue stats queries.
+func (s *Store) backlogged(e *event, now time.Time) bool {
+	return e.Status == db.ReceivedStatus &&
+		!e.NextAttemptAt.Time.After(now) &&
+		!s.paused(typeOf(e))
+}
+
+func (s *Store) paused(eventType string) bool {
+	for _, c := range s.controls {
+		if c.Paused && (c.Scope == "*" || c.Scope == eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func olderThan(a, b *event) bool {
+	if c := a.ReceivedAt.Time.Compare(b.ReceivedAt.Time); c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+// partitionOf is the event's partition when claims are restricted by
+// partitionBy, and partition itself (so every event matches) when they
+// are not.
+func partitionOf(e *event, partitionBy, partition string) string {
+	switch partitionBy {
+	case "tenant", "type":
+		return partitionKey(e, partitionBy)
+	default:
+		return partition
+	}
+}
+
+func partitionKey(e *event, partitionBy string) string {
+	if partitionBy == "tenant" {
+		return e.TenantID
+	}
+	return typeOf(e)
+}
+
+func typeOf(e *event) string {
+	if e.Type == nil {
+		return ""
+	}
+	return *e.Type
+}
+
+// Webhook attempts
+
+func (s *Store) CreateWebhookAttempt(ctx context.Context, arg sqlc.CreateWebhookAttemptParams) (sqlc.WebhookAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.WebhookAttempt{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.event(arg.WebhookEventID) == nil {
+		return sqlc.WebhookAttempt{}, violation(ForeignKeyViolation, "webhook_attempts_webhook_event_id_fkey",
+			"insert or update on table \"webhook_attempts\" violates foreign key constraint")
+	}
+	validOutcome := slices.Contains([]string{db.AttemptSucceeded, db.AttemptRetrying, db.AttemptFailed}, arg.Outcome)
+	validReason := arg.Reason == nil ||
+		slices.Contains([]string{db.AttemptReasonError, db.AttemptReasonPanic, db.AttemptReasonTimeout}, *arg.Reason)
+	if !validOutcome || !validReason || arg.Attempt < 1 {
+		return sqlc.WebhookAttempt{}, violation(CheckViolation, "webhook_attempts_check",
+			"new row for relation \"webhook_attempts\" violates check constraint")
+	}
+
+	attempt := sqlc.WebhookAttempt{
+		ID:             uuid.New(),
+		WebhookEventID: arg.WebhookEventID,
+		Attempt:        arg.Attempt,
+		WorkerID:       arg.WorkerID,
+		Host:           arg.Host,
+		StartedAt:      arg.StartedAt,
+		FinishedAt:     arg.FinishedAt,
+		Outcome:        arg.Outcome,
+		Error:          clonePtr(arg.Error),
+		DurationMs:     arg.DurationMs,
+		Reason:         clonePtr(arg.Reason),
+	}
+	s.attempts = append(s.attempts, attempt)
+	return attempt, nil
+}
+
+func (s *Store) ListWebhookAttempts(ctx context.Context, webhookEventID uuid.UUID) ([]sqlc.WebhookAttempt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var attempts []sqlc.WebhookAttempt
+	for _, a := range s.attempts {
+		if a.WebhookEventID == webhookEventID {
+			attempts = append(attempts, a)
+		}
+	}
+	slices.SortStableFunc(attempts, func(a, b sqlc.WebhookAttempt) int {
+		return cmp.Or(cmp.Compare(a.Attempt, b.Attempt), a.StartedAt.Time.Compare(b.StartedAt.Time))
+	})
+	return attempts, nil
+}
+
+// Workers
+
+func (s *Store) RegisterWorker(ctx context.Context, arg sqlc.RegisterWorkerParams) (sqlc.Worker, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.Worker{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.workers[arg.ID]; ok {
+		return sqlc.Worker{}, violation(UniqueViolation, "workers_pkey", "duplicate key value violates unique constraint")
+	}
+	now := timestamptz(s.now())
+	worker := sqlc.Worker{
+		ID:          arg.ID,
+		Host:        arg.Host,
+		Pid:         arg.Pid,
+		Version:     arg.Version,
+		Concurrency: arg.Concurrency,
+		StartedAt:   now,
+		HeartbeatAt: now,
+	}
+	s.workers[arg.ID] = worker
+	return worker, nil
+}
+
+func (s *Store) HeartbeatWorker(ctx context.Context, arg sqlc.HeartbeatWorkerParams) (sqlc.Worker, error) {
+	return s.updateWorker(ctx, arg.ID, func(w *sqlc.Worker, now time.Time) {
+		w.HeartbeatAt = timestamptz(now)
+		w.Concurrency = arg.Concurrency
+	})
+}
+
+func (s *Store) StopWorker(ctx context.Context, id uuid.UUID) (sqlc.Worker, error) {
+	return s.updateWorker(ctx, id, func(w *sqlc.Worker, now time.Time) {
+		w.StoppedAt = timestamp(now)
+		w.HeartbeatAt = timestamptz(now)
+	})
+}
+
+func (s *Store) ListWorkers(ctx context.Context) ([]sqlc.Worker, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workers := make([]sqlc.Worker, 0, len(s.workers))
+	for _, w := range s.workers {
+		workers = append(workers, w)
+	}
+	slices.SortFunc(workers, func(a, b sqlc.Worker) int {
+		return cmp.Or(b.StartedAt.Time.Compare(a.StartedAt.Time), bytes.Compare(a.ID[:], b.ID[:]))
+	})
+	return workers, nil
+}
+
+func (s *Store) updateWorker(ctx context.Context, id uuid.UUID, fn func(w *sqlc.Worker, now time.Time)) (sqlc.Worker, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.Worker{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.workers[id]
+	if !ok {
+		return sqlc.Worker{}, pgx.ErrNoRows
+	}
+	fn(&w, s.now())
+	s.workers[id] = w
+	return w, nil
+}
+
+// Queue controls
+
+func (s *Store) SetQueuePaused(ctx context.Context, arg sqlc.SetQueuePausedParams) (sqlc.QueueControl, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.QueueControl{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	control := sqlc.QueueControl{
+		Scope:     arg.Scope,
+		Paused:    arg.Paused,
+		Reason:    clonePtr(arg.Reason),
+		UpdatedAt: timestamptz(s.now()),
+	}
+	s.controls[arg.Scope] = control
+	return control, nil
+}
+
+func (s *Store) ListQueueControls(ctx context.Context) ([]sqlc.QueueControl, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	controls := make([]sqlc.QueueControl, 0, len(s.controls))
+	for _, c := range s.controls {
+		controls = append(controls, c)
+	}
+	slices.SortFunc(controls, func(a, b sqlc.QueueControl) int { return cmp.Compare(a.Scope, b.Scope) })
+	return controls, nil
+}
+
+// API keys
+
+func (s *Store) CreateAPIKey(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.ApiKey{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == arg.KeyHash {
+			return sqlc.ApiKey{}, violation(UniqueViolation, "api_keys_key_hash_key", "duplicate key value violates unique constraint")
+		}
+	}
+	if len(arg.Scopes) == 0 || slices.ContainsFunc(arg.Scopes, func(scope string) bool {
+		return !slices.Contains([]string{"ingest", "read", "admin"}, scope)
+	}) {
+		return sqlc.ApiKey{}, violation(CheckViolation, "api_keys_scopes_check",
+			"new row for relation \"api_keys\" violates check constraint")
+	}
+
+	key := sqlc.ApiKey{
+		ID:        uuid.New(),
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		TenantID:  arg.TenantID,
+		Scopes:    slices.Clone(arg.Scopes),
+		CreatedAt: timestamptz(s.now()),
+	}
+	s.apiKeys = append(s.apiKeys, key)
+	return cloneKey(key), nil
+}
+
+func (s *Store) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (sqlc.ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.ApiKey{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == keyHash && !k.RevokedAt.Valid {
+			return cloneKey(k), nil
+		}
+	}
+	return sqlc.ApiKey{}, pgx.ErrNoRows
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.apiKeys {
+		if s.apiKeys[i].ID == id {
+			s.apiKeys[i].LastUsedAt = timestamp(s.now())
+		}
+	}
+	return nil
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, id uuid.UUID) (sqlc.ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return sqlc.ApiKey{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.apiKeys {
+		if s.apiKeys[i].ID == id {
+			if !s.apiKeys[i].RevokedAt.Valid {
+				s.apiKeys[i].RevokedAt = timestamp(s.now())
+			}
+			return cloneKey(s.apiKeys[i]), nil
+		}
+	}
+	return sqlc.ApiKey{}, pgx.ErrNoRows
+}
+
+func (s *Store) ListAPIKeys(ctx context.Context) ([]sqlc.ApiKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]sqlc.ApiKey, len(s.apiKeys))
+	for i, k := range s.apiKeys {
+		// Newest first; among keys created at the same instant, the later one.
+		keys[len(keys)-1-i] = cloneKey(k)
+	}
+	slices.SortStableFunc(keys, func(a, b sqlc.ApiKey) int { return b.CreatedAt.Time.Compare(a.CreatedAt.Time) })
+	return keys, nil
+}
+
+func violation(code, constraint, message string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           code,
+		Message:        fmt.Sprintf("%s %q", message, constraint),
+		ConstraintName: constraint,
+	}
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func timestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t, Valid: true}
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func cloneEvent(e sqlc.WebhookEvent) sqlc.WebhookEvent {
+	e.Type = clonePtr(e.Type)
+	e.LastError = clonePtr(e.LastError)
+	e.Payload = bytes.Clone(e.Payload)
+	return e
+}
+
+func cloneKey(k sqlc.ApiKey) sqlc.ApiKey {
+	k.Scopes = slices.Clone(k.Scopes)
+	return k
+}
@@ -0,0 +1,342 @@
+package dbtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createEvent(t *testing.T, store *dbtest.Store, tenant, eventID, eventType string) sqlc.WebhookEvent {
+	t.Helper()
+	event, err := store.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{
+		TenantID: tenant,
+		EventID:  eventID,
+		Type:     &eventType,
+		Payload:  []byte(`{}`),
+	})
+	require.NoError(t, err)
+	return event
+}
+
+func registerWorker(t *testing.T, store *dbtest.Store) uuid.UUID {
+	t.Helper()
+	worker, err := store.RegisterWorker(context.Background(), sqlc.RegisterWorkerParams{ID: uuid.New(), Host: "test"})
+	require.NoError(t, err)
+	return worker.ID
+}
+
+func TestStore_CreateWebhookUniquePerTenant(t *testing.T) {
+	store := dbtest.NewStore()
+	createEvent(t, store, "acme", "evt_1", "payment.completed")
+
+	_, err := store.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{TenantID: "acme", EventID: "evt_1"})
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, dbtest.UniqueViolation, pgErr.Code)
+	assert.Equal(t, "webhook_events_tenant_event_id_key", pgErr.ConstraintName)
+
+	createEvent(t, store, "globex", "evt_1", "payment.completed")
+	assert.Len(t, store.Events(), 2)
+}
+
+func TestStore_ClaimLifecycle(t *testing.T) {
+	store := dbtest.NewStore()
+	ctx := context.Background()
+	created := createEvent(t, store, "acme", "evt_1", "payment.completed")
+	workerID := registerWorker(t, store)
+
+	claimed, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, claimed.ID)
+	assert.Equal(t, db.ProcessingStatus, claimed.Status)
+	assert.EqualValues(t, 1, claimed.Attempts)
+	assert.Equal(t, pgtype.UUID{Bytes: workerID, Valid: true}, claimed.ClaimedBy)
+
+	processing, err := store.ListProcessingWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, processing, 1)
+
+	_, err = store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	lastError := "boom"
+	retried, err := store.RetryWebhook(ctx, sqlc.RetryWebhookParams{
+		ID:            claimed.ID,
+		ClaimedBy:     workerID,
+		LastError:     &lastError,
+		NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, db.ReceivedStatus, retried.Status)
+
+	claimed, err = store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, claimed.Attempts)
+
+	_, err = store.MarkWebhookDone(ctx, sqlc.MarkWebhookDoneParams{ID: claimed.ID, ClaimedBy: uuid.New()})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "only the claiming pool settles")
+
+	done, err := store.MarkWebhookDone(ctx, sqlc.MarkWebhookDoneParams{ID: claimed.ID, ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.Equal(t, db.DoneStatus, done.Status)
+	assert.True(t, done.ProcessedAt.Valid)
+
+	_, err = store.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{ID: claimed.ID, ClaimedBy: workerID, LastError: &lastError})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "a settled event is not settled again")
+
+	_, err = store.MarkWebhookDone(ctx, sqlc.MarkWebhookDoneParams{ID: uuid.New(), ClaimedBy: workerID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestStore_ClaimNeedsRegisteredWorker(t *testing.T) {
+	store := dbtest.NewStore()
+	createEvent(t, store, "acme", "evt_1", "payment.completed")
+
+	_, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: uuid.New()})
+
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, dbtest.ForeignKeyViolation, pgErr.Code)
+	assert.Equal(t, db.ReceivedStatus, store.Events()[0].Status)
+}
+
+func TestStore_ReleaseWebhook(t *testing.T) {
+	store := dbtest.NewStore()
+	ctx := context.Background()
+	createEvent(t, store, "acme", "evt_1", "payment.completed")
+	workerID := registerWorker(t, store)
+	claimed, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	require.NoError(t, err)
+
+	_, err = store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: claimed.ID, ClaimedBy: uuid.New()})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "only the claiming pool releases")
+
+	released, err := store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: claimed.ID, ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.Equal(t, db.ReceivedStatus, released.Status)
+	assert.Zero(t, released.Attempts)
+	assert.False(t, released.ClaimedBy.Valid)
+
+	_, err = store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: claimed.ID, ClaimedBy: workerID})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	var pgErr *pgconn.PgError
+	store.PutEvent(sqlc.WebhookEvent{ID: claimed.ID, Status: db.ProcessingStatus, ClaimedBy: pgtype.UUID{Bytes: workerID, Valid: true}})
+	_, err = store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: claimed.ID, ClaimedBy: workerID})
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, dbtest.CheckViolation, pgErr.Code)
+}
+
+func TestStore_LeaseRenewalAndReclaim(t *testing.T) {
+	now := time.Now()
+	store := dbtest.NewStore()
+	store.Now = func() time.Time { return now }
+	ctx := context.Background()
+	live := registerWorker(t, store)
+	stopped := registerWorker(t, store)
+	_, err := store.StopWorker(ctx, stopped)
+	require.NoError(t, err)
+
+	claim := func(eventID string, workerID uuid.UUID) sqlc.WebhookEvent {
+		createEvent(t, store, "acme", eventID, "payment.completed")
+		claimed, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+		require.NoError(t, err)
+		return claimed
+	}
+	renewed := claim("evt_1", live)
+	expired := claim("evt_2", live)
+	orphaned := claim("evt_3", stopped)
+
+	now = now.Add(time.Minute)
+	_, err = store.HeartbeatWorker(ctx, sqlc.HeartbeatWorkerParams{ID: live})
+	require.NoError(t, err)
+	_, err = store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: renewed.ID, ClaimedBy: live})
+	require.NoError(t, err)
+	_, err = store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: expired.ID, ClaimedBy: stopped})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "only the claiming worker can renew")
+
+	reclaimed, err := store.ReclaimExpiredWebhooks(ctx, 30)
+	require.NoError(t, err)
+	ids := []uuid.UUID{}
+	for _, event := range reclaimed {
+		assert.Equal(t, db.ReceivedStatus, event.Status)
+		assert.False(t, event.ClaimedBy.Valid)
+		ids = append(ids, event.ID)
+	}
+	assert.ElementsMatch(t, []uuid.UUID{expired.ID, orphaned.ID}, ids)
+
+	_, err = store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: expired.ID, ClaimedBy: live})
+	assert.ErrorIs(t, err, pgx.ErrNoRows, "a reclaimed lease cannot be renewed")
+	next, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: live})
+	require.NoError(t, err)
+	assert.Equal(t, expired.ID, next.ID)
+}
+
+func TestStore_ClaimOldestFirst(t *testing.T) {
+	now := time.Now()
+	store := dbtest.NewStore()
+	store.PutEvent(sqlc.WebhookEvent{EventID: "evt_newest", ReceivedAt: pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}})
+	store.PutEvent(sqlc.WebhookEvent{EventID: "evt_oldest", ReceivedAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}})
+	store.PutEvent(sqlc.WebhookEvent{EventID: "evt_tie", ReceivedAt: pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}})
+	workerID := registerWorker(t, store)
+
+	var order []string
+	for range 3 {
+		event, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+		require.NoError(t, err)
+		order = append(order, event.EventID)
+	}
+
+	assert.Equal(t, []string{"evt_oldest", "evt_newest", "evt_tie"}, order)
+}
+
+func TestStore_ClaimSkipsUnclaimableEvents(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, store *dbtest.Store, event sqlc.WebhookEvent)
+		params sqlc.ClaimNextWebhookParams
+	}{
+		{
+			name: "not due for retry",
+			setup: func(t *testing.T, store *dbtest.Store, event sqlc.WebhookEvent) {
+				event.NextAttemptAt = pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true}
+				store.PutEvent(event)
+			},
+		},
+		{
+			name: "type paused",
+			setup: func(t *testing.T, store *dbtest.Store, event sqlc.WebhookEvent) {
+				_, err := store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{Scope: "payment.refunded", Paused: true})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "everything paused",
+			setup: func(t *testing.T, store *dbtest.Store, event sqlc.WebhookEvent) {
+				_, err := store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{Scope: "*", Paused: true})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "type excluded",
+			params: sqlc.ClaimNextWebhookParams{ExcludedTypes: []string{"payment.refunded"}},
+		},
+		{
+			name:   "other tenant's turn",
+			params: sqlc.ClaimNextWebhookParams{PartitionBy: "tenant", Partition: "globex"},
+		},
+		{
+			name:   "other type's turn",
+			params: sqlc.ClaimNextWebhookParams{PartitionBy: "type", Partition: "payment.completed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := dbtest.NewStore()
+			event := createEvent(t, store, "acme", "evt_1", "payment.refunded")
+			if tt.setup != nil {
+				tt.setup(t, store, event)
+			}
+
+			params := tt.params
+			params.ClaimedBy = registerWorker(t, store)
+			_, err := store.ClaimNextWebhook(ctx, params)
+
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+		})
+	}
+}
+
+func TestStore_ConcurrentClaimersNeverShareAnEvent(t *testing.T) {
+	store := dbtest.NewStore()
+	ctx := context.Background()
+	const events, claimers = 500, 16
+	for i := range events {
+		createEvent(t, store, fmt.Sprintf("tenant-%d", i%3), fmt.Sprintf("evt_%d", i), "payment.completed")
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[uuid.UUID]int)
+		wg      sync.WaitGroup
+	)
+	for range claimers {
+		workerID := registerWorker(t, store)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				event, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+				if err != nil {
+					assert.True(t, errors.Is(err, pgx.ErrNoRows), err)
+					return
+				}
+				mu.Lock()
+				claimed[event.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, events)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, "event %s", id)
+	}
+}
+
+func TestStore_QueueStats(t *testing.T) {
+	now := time.Now()
+	store := dbtest.NewStore()
+	store.Now = func() time.Time { return now }
+	ctx := context.Background()
+	paused := "payment.refunded"
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "acme", EventID: "evt_1", ReceivedAt: pgtype.Timestamptz{Time: now.Add(-time.Minute), Valid: true}})
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "acme", EventID: "evt_2", ReceivedAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}, NextAttemptAt: pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true}})
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "globex", EventID: "evt_3"})
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "globex", EventID: "evt_4", Type: &paused})
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "globex", EventID: "evt_5", Status: db.DoneStatus})
+	_, err := store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{Scope: paused, Paused: true})
+	require.NoError(t, err)
+
+	stats, err := store.GetQueueStats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stats.Depth)
+	assert.InDelta(t, 60, stats.OldestAgeSeconds, 0.001, "a retry that is not due yet does not age the backlog")
+
+	tenants, err := store.GetTenantQueueStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []sqlc.GetTenantQueueStatsRow{{TenantID: "acme", Depth: 1}, {TenantID: "globex", Depth: 1}}, tenants)
+
+	partitions, err := store.ListClaimablePartitions(ctx, sqlc.ListClaimablePartitionsParams{PartitionBy: "tenant"})
+	require.NoError(t, err)
+	assert.Equal(t, []sqlc.ListClaimablePartitionsRow{{Partition: "acme", Depth: 1}, {Partition: "globex", Depth: 1}}, partitions)
+}
+
+func TestStore_CancelledContext(t *testing.T) {
+	store := dbtest.NewStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := store.CreateWebhook(ctx, sqlc.CreateWebhookParams{TenantID: "acme", EventID: "evt_1"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, store.Events())
+}
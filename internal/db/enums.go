@@ -6,3 +6,17 @@ const (
 	DoneStatus       string = "done"
 	FailedStatus     string = "failed"
 )
+
+// Attempt outcomes recorded in webhook_attempts.
+const (
+	AttemptSucceeded string = "succeeded"
+	AttemptRetrying  string = "retrying"
+	AttemptFailed    string = "failed"
+)
+
+// Why a failed attempt failed, recorded in webhook_attempts.reason.
+const (
+	AttemptReasonError   string = "error"
+	AttemptReasonPanic   string = "panic"
+	AttemptReasonTimeout string = "timeout"
+)
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// CheckSchema returns an error unless the database is migrated to at least
+// the version embedded in this binary and is not left dirty. Newer versions
+// pass, so old replicas stay ready while a rolling deploy migrates ahead.
+func CheckSchema(ctx context.Context, store Store) error {
+	expected, err := ExpectedVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, ok, err := store.SchemaVersion(ctx)
+	switch {
+	case err != nil:
+		return fmt.Errorf("read schema version: %w", err)
+	case !ok:
+		return fmt.Errorf("no migrations applied, want version %d", expected)
+	case dirty:
+		return fmt.Errorf("migration %d is dirty", version)
+	case version < expected:
+		return fmt.Errorf("schema at version %d, want %d", version, expected)
+	}
+	return nil
+}
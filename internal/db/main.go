@@ -2,9 +2,14 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
+	"worker-pool/internal/config"
+	"worker-pool/internal/metrics"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -19,23 +24,110 @@ type PostgresConfig struct {
 	Environment string
 }
 
-func InitPostgres(dsn string) (Store, error) {
-	runDBMigration(dsn)
+// InitPostgres connects to Postgres, retrying with backoff while the
+// database is unreachable, and migrates it when cfg.AutoMigrate is set. Pool
+// statistics are logged every cfg.StatsInterval until ctx is cancelled.
+func InitPostgres(ctx context.Context, cfg config.DatabaseConfig) (Store, error) {
+	poolConfig, err := newPoolConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := connectWithRetry(ctx, poolConfig, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Int32("max_conns", poolConfig.MaxConns).
+		Int32("min_conns", poolConfig.MinConns).
+		Msg("PostgreSQL connection via pgx/v5 successful")
+
+	if cfg.AutoMigrate {
+		if err := runDBMigration(ctx, cfg.URL); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+
+	if err := metrics.Registry.Register(metrics.NewDBPoolCollector(pool)); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			log.Warn().Err(err).Msg("Failed to register database pool metrics")
+		}
+	}
+
+	if cfg.StatsInterval > 0 {
+		go logPoolStats(ctx, pool, cfg.StatsInterval)
+	}
+
+	store := NewStore(pool)
+	return store, nil
+}
+
+func newPoolConfig(cfg config.DatabaseConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database url: %w", err)
+	}
+
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	if cfg.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return poolConfig, nil
+}
+
+func connectWithRetry(ctx context.Context, poolConfig *pgxpool.Config, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	backoff := cfg.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		pool, err := connect(ctx, poolConfig, cfg.ConnectTimeout)
+		if err == nil {
+			return pool, nil
+		}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if attempt >= cfg.ConnectAttempts {
+			return nil, fmt.Errorf("failed to connect after %d attempts: %w", attempt, err)
+		}
+
+		log.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Int("max_attempts", cfg.ConnectAttempts).
+			Dur("retry_in", backoff).
+			Msg("Database unavailable, retrying")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cfg.ConnectMaxBackoff)
+	}
+}
+
+func connect(ctx context.Context, poolConfig *pgxpool.Config, timeout time.Duration) (*pgxpool.Pool, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, dsn)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig.Copy())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pgx pool: %w", err)
 	}
 
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping pgx pool: %w", err)
 	}
 
-	log.Info().Msg("PostgreSQL connection via pgx/v5 successful")
-
-	store := NewStore(pool)
-	return store, nil
+	return pool, nil
 }
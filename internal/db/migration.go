@@ -1,23 +1,151 @@
 package db
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"worker-pool/internal/db/sqlc/migrations"
+
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
-func runDBMigration(dbSource string) {
-	migrationURL := "file://internal/db/sqlc/migrations"
+// migrationLockID is the pg_advisory_lock key held for the whole of a
+// migration command, so replicas starting together migrate one at a time.
+const migrationLockID int64 = 0x776b7231 // "wkr1"
+
+// Migrator runs the embedded schema migrations against one database.
+type Migrator struct {
+	dsn string
+	m   *migrate.Migrate
+}
+
+func NewMigrator(dsn string) (*Migrator, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("open embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", source, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("create migrate instance: %w", err)
+	}
+
+	return &Migrator{dsn: dsn, m: m}, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		return ignoreNoChange(m.m.Up())
+	})
+}
+
+// Down rolls back the given number of migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+	return m.withLock(ctx, func() error {
+		return ignoreNoChange(m.m.Steps(-steps))
+	})
+}
+
+// Force records version as applied and clears the dirty flag without
+// running any SQL, for recovering from a failed migration.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.withLock(ctx, func() error {
+		return m.m.Force(version)
+	})
+}
+
+// Version reports the applied version; ok is false on a fresh database.
+func (m *Migrator) Version() (version uint, dirty bool, ok bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	return version, dirty, true, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	conn, err := pgx.Connect(ctx, m.dsn)
+	if err != nil {
+		return fmt.Errorf("connect for migration lock: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			log.Warn().Err(err).Msg("Failed to release migration lock")
+		}
+	}()
+
+	return fn()
+}
 
-	migration, err := migrate.New(migrationURL, dbSource)
+// ExpectedVersion is the newest migration embedded in this binary.
+func ExpectedVersion() (uint, error) {
+	source, err := iofs.New(migrations.FS, ".")
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create new migrate instance")
+		return 0, fmt.Errorf("open embedded migrations: %w", err)
 	}
+	defer source.Close()
 
-	if err = migration.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal().Err(err).Msg("failed to run migrate up")
+	version, err := source.First()
+	if err != nil {
+		return 0, fmt.Errorf("read first migration: %w", err)
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read migration after %d: %w", version, err)
+		}
+		version = next
 	}
+}
 
-	log.Info().Msg("db migrated successfully")
+func runDBMigration(ctx context.Context, dsn string) error {
+	migrator, err := NewMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+
+	version, _, _, err := migrator.Version()
+	if err != nil {
+		return fmt.Errorf("read migration version: %w", err)
+	}
+
+	log.Info().Uint("version", version).Msg("db migrated successfully")
+	return nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
 }
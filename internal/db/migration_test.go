@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+	"worker-pool/internal/db/sqlc/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	require.NoError(t, err)
+
+	var latest uint64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		require.NoError(t, err, entry.Name())
+		latest = max(latest, v)
+	}
+
+	version, err := ExpectedVersion()
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(latest), version)
+}
+
+// schemaStore stubs only SchemaVersion; every other Store method is unused.
+type schemaStore struct {
+	Store
+	version uint
+	dirty   bool
+	ok      bool
+	err     error
+}
+
+func (s schemaStore) SchemaVersion(ctx context.Context) (uint, bool, bool, error) {
+	return s.version, s.dirty, s.ok, s.err
+}
+
+func TestCheckSchema(t *testing.T) {
+	expected, err := ExpectedVersion()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		store   schemaStore
+		wantErr string
+	}{
+		{"at expected version", schemaStore{version: expected, ok: true}, ""},
+		{"ahead of this build", schemaStore{version: expected + 1, ok: true}, ""},
+		{"behind", schemaStore{version: expected - 1, ok: true}, "want"},
+		{"dirty", schemaStore{version: expected, dirty: true, ok: true}, "dirty"},
+		{"never migrated", schemaStore{}, "no migrations applied"},
+		{"query error", schemaStore{err: errors.New("relation does not exist")}, "read schema version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchema(context.Background(), tt.store)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
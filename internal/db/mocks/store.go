@@ -0,0 +1,1638 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	uuid "github.com/google/uuid"
+)
+
+// Store is an autogenerated mock type for the Store type
+type Store struct {
+	mock.Mock
+}
+
+type Store_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *Store) EXPECT() *Store_Expecter {
+	return &Store_Expecter{mock: &_m.Mock}
+}
+
+// ClaimNextWebhook provides a mock function with given fields: ctx, arg
+func (_m *Store) ClaimNextWebhook(ctx context.Context, arg sqlc.ClaimNextWebhookParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimNextWebhook")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ClaimNextWebhookParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ClaimNextWebhookParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.ClaimNextWebhookParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ClaimNextWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimNextWebhook'
+type Store_ClaimNextWebhook_Call struct {
+	*mock.Call
+}
+
+// ClaimNextWebhook is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.ClaimNextWebhookParams
+func (_e *Store_Expecter) ClaimNextWebhook(ctx interface{}, arg interface{}) *Store_ClaimNextWebhook_Call {
+	return &Store_ClaimNextWebhook_Call{Call: _e.mock.On("ClaimNextWebhook", ctx, arg)}
+}
+
+func (_c *Store_ClaimNextWebhook_Call) Run(run func(ctx context.Context, arg sqlc.ClaimNextWebhookParams)) *Store_ClaimNextWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.ClaimNextWebhookParams))
+	})
+	return _c
+}
+
+func (_c *Store_ClaimNextWebhook_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_ClaimNextWebhook_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ClaimNextWebhook_Call) RunAndReturn(run func(context.Context, sqlc.ClaimNextWebhookParams) (sqlc.WebhookEvent, error)) *Store_ClaimNextWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateAPIKey(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 sqlc.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateAPIKeyParams) sqlc.ApiKey); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.ApiKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.CreateAPIKeyParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type Store_CreateAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.CreateAPIKeyParams
+func (_e *Store_Expecter) CreateAPIKey(ctx interface{}, arg interface{}) *Store_CreateAPIKey_Call {
+	return &Store_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey", ctx, arg)}
+}
+
+func (_c *Store_CreateAPIKey_Call) Run(run func(ctx context.Context, arg sqlc.CreateAPIKeyParams)) *Store_CreateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.CreateAPIKeyParams))
+	})
+	return _c
+}
+
+func (_c *Store_CreateAPIKey_Call) Return(_a0 sqlc.ApiKey, _a1 error) *Store_CreateAPIKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_CreateAPIKey_Call) RunAndReturn(run func(context.Context, sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error)) *Store_CreateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateWebhookParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.CreateWebhookParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_CreateWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWebhook'
+type Store_CreateWebhook_Call struct {
+	*mock.Call
+}
+
+// CreateWebhook is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.CreateWebhookParams
+func (_e *Store_Expecter) CreateWebhook(ctx interface{}, arg interface{}) *Store_CreateWebhook_Call {
+	return &Store_CreateWebhook_Call{Call: _e.mock.On("CreateWebhook", ctx, arg)}
+}
+
+func (_c *Store_CreateWebhook_Call) Run(run func(ctx context.Context, arg sqlc.CreateWebhookParams)) *Store_CreateWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.CreateWebhookParams))
+	})
+	return _c
+}
+
+func (_c *Store_CreateWebhook_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_CreateWebhook_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_CreateWebhook_Call) RunAndReturn(run func(context.Context, sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error)) *Store_CreateWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateWebhookAttempt provides a mock function with given fields: ctx, arg
+func (_m *Store) CreateWebhookAttempt(ctx context.Context, arg sqlc.CreateWebhookAttemptParams) (sqlc.WebhookAttempt, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookAttempt")
+	}
+
+	var r0 sqlc.WebhookAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateWebhookAttemptParams) (sqlc.WebhookAttempt, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.CreateWebhookAttemptParams) sqlc.WebhookAttempt); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookAttempt)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.CreateWebhookAttemptParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_CreateWebhookAttempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateWebhookAttempt'
+type Store_CreateWebhookAttempt_Call struct {
+	*mock.Call
+}
+
+// CreateWebhookAttempt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.CreateWebhookAttemptParams
+func (_e *Store_Expecter) CreateWebhookAttempt(ctx interface{}, arg interface{}) *Store_CreateWebhookAttempt_Call {
+	return &Store_CreateWebhookAttempt_Call{Call: _e.mock.On("CreateWebhookAttempt", ctx, arg)}
+}
+
+func (_c *Store_CreateWebhookAttempt_Call) Run(run func(ctx context.Context, arg sqlc.CreateWebhookAttemptParams)) *Store_CreateWebhookAttempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.CreateWebhookAttemptParams))
+	})
+	return _c
+}
+
+func (_c *Store_CreateWebhookAttempt_Call) Return(_a0 sqlc.WebhookAttempt, _a1 error) *Store_CreateWebhookAttempt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_CreateWebhookAttempt_Call) RunAndReturn(run func(context.Context, sqlc.CreateWebhookAttemptParams) (sqlc.WebhookAttempt, error)) *Store_CreateWebhookAttempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetActiveAPIKeyByHash provides a mock function with given fields: ctx, keyHash
+func (_m *Store) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (sqlc.ApiKey, error) {
+	ret := _m.Called(ctx, keyHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveAPIKeyByHash")
+	}
+
+	var r0 sqlc.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (sqlc.ApiKey, error)); ok {
+		return rf(ctx, keyHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) sqlc.ApiKey); ok {
+		r0 = rf(ctx, keyHash)
+	} else {
+		r0 = ret.Get(0).(sqlc.ApiKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_GetActiveAPIKeyByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActiveAPIKeyByHash'
+type Store_GetActiveAPIKeyByHash_Call struct {
+	*mock.Call
+}
+
+// GetActiveAPIKeyByHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - keyHash string
+func (_e *Store_Expecter) GetActiveAPIKeyByHash(ctx interface{}, keyHash interface{}) *Store_GetActiveAPIKeyByHash_Call {
+	return &Store_GetActiveAPIKeyByHash_Call{Call: _e.mock.On("GetActiveAPIKeyByHash", ctx, keyHash)}
+}
+
+func (_c *Store_GetActiveAPIKeyByHash_Call) Run(run func(ctx context.Context, keyHash string)) *Store_GetActiveAPIKeyByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Store_GetActiveAPIKeyByHash_Call) Return(_a0 sqlc.ApiKey, _a1 error) *Store_GetActiveAPIKeyByHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_GetActiveAPIKeyByHash_Call) RunAndReturn(run func(context.Context, string) (sqlc.ApiKey, error)) *Store_GetActiveAPIKeyByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetQueueStats provides a mock function with given fields: ctx
+func (_m *Store) GetQueueStats(ctx context.Context) (sqlc.GetQueueStatsRow, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQueueStats")
+	}
+
+	var r0 sqlc.GetQueueStatsRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (sqlc.GetQueueStatsRow, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) sqlc.GetQueueStatsRow); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(sqlc.GetQueueStatsRow)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_GetQueueStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetQueueStats'
+type Store_GetQueueStats_Call struct {
+	*mock.Call
+}
+
+// GetQueueStats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) GetQueueStats(ctx interface{}) *Store_GetQueueStats_Call {
+	return &Store_GetQueueStats_Call{Call: _e.mock.On("GetQueueStats", ctx)}
+}
+
+func (_c *Store_GetQueueStats_Call) Run(run func(ctx context.Context)) *Store_GetQueueStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_GetQueueStats_Call) Return(_a0 sqlc.GetQueueStatsRow, _a1 error) *Store_GetQueueStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_GetQueueStats_Call) RunAndReturn(run func(context.Context) (sqlc.GetQueueStatsRow, error)) *Store_GetQueueStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTenantQueueStats provides a mock function with given fields: ctx
+func (_m *Store) GetTenantQueueStats(ctx context.Context) ([]sqlc.GetTenantQueueStatsRow, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTenantQueueStats")
+	}
+
+	var r0 []sqlc.GetTenantQueueStatsRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]sqlc.GetTenantQueueStatsRow, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []sqlc.GetTenantQueueStatsRow); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.GetTenantQueueStatsRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_GetTenantQueueStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTenantQueueStats'
+type Store_GetTenantQueueStats_Call struct {
+	*mock.Call
+}
+
+// GetTenantQueueStats is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) GetTenantQueueStats(ctx interface{}) *Store_GetTenantQueueStats_Call {
+	return &Store_GetTenantQueueStats_Call{Call: _e.mock.On("GetTenantQueueStats", ctx)}
+}
+
+func (_c *Store_GetTenantQueueStats_Call) Run(run func(ctx context.Context)) *Store_GetTenantQueueStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_GetTenantQueueStats_Call) Return(_a0 []sqlc.GetTenantQueueStatsRow, _a1 error) *Store_GetTenantQueueStats_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_GetTenantQueueStats_Call) RunAndReturn(run func(context.Context) ([]sqlc.GetTenantQueueStatsRow, error)) *Store_GetTenantQueueStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWebhookByEventID provides a mock function with given fields: ctx, arg
+func (_m *Store) GetWebhookByEventID(ctx context.Context, arg sqlc.GetWebhookByEventIDParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookByEventID")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.GetWebhookByEventIDParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.GetWebhookByEventIDParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.GetWebhookByEventIDParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_GetWebhookByEventID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWebhookByEventID'
+type Store_GetWebhookByEventID_Call struct {
+	*mock.Call
+}
+
+// GetWebhookByEventID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.GetWebhookByEventIDParams
+func (_e *Store_Expecter) GetWebhookByEventID(ctx interface{}, arg interface{}) *Store_GetWebhookByEventID_Call {
+	return &Store_GetWebhookByEventID_Call{Call: _e.mock.On("GetWebhookByEventID", ctx, arg)}
+}
+
+func (_c *Store_GetWebhookByEventID_Call) Run(run func(ctx context.Context, arg sqlc.GetWebhookByEventIDParams)) *Store_GetWebhookByEventID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.GetWebhookByEventIDParams))
+	})
+	return _c
+}
+
+func (_c *Store_GetWebhookByEventID_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_GetWebhookByEventID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_GetWebhookByEventID_Call) RunAndReturn(run func(context.Context, sqlc.GetWebhookByEventIDParams) (sqlc.WebhookEvent, error)) *Store_GetWebhookByEventID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HeartbeatWorker provides a mock function with given fields: ctx, arg
+func (_m *Store) HeartbeatWorker(ctx context.Context, arg sqlc.HeartbeatWorkerParams) (sqlc.Worker, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HeartbeatWorker")
+	}
+
+	var r0 sqlc.Worker
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.HeartbeatWorkerParams) (sqlc.Worker, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.HeartbeatWorkerParams) sqlc.Worker); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.Worker)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.HeartbeatWorkerParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_HeartbeatWorker_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HeartbeatWorker'
+type Store_HeartbeatWorker_Call struct {
+	*mock.Call
+}
+
+// HeartbeatWorker is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.HeartbeatWorkerParams
+func (_e *Store_Expecter) HeartbeatWorker(ctx interface{}, arg interface{}) *Store_HeartbeatWorker_Call {
+	return &Store_HeartbeatWorker_Call{Call: _e.mock.On("HeartbeatWorker", ctx, arg)}
+}
+
+func (_c *Store_HeartbeatWorker_Call) Run(run func(ctx context.Context, arg sqlc.HeartbeatWorkerParams)) *Store_HeartbeatWorker_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.HeartbeatWorkerParams))
+	})
+	return _c
+}
+
+func (_c *Store_HeartbeatWorker_Call) Return(_a0 sqlc.Worker, _a1 error) *Store_HeartbeatWorker_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_HeartbeatWorker_Call) RunAndReturn(run func(context.Context, sqlc.HeartbeatWorkerParams) (sqlc.Worker, error)) *Store_HeartbeatWorker_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx
+func (_m *Store) ListAPIKeys(ctx context.Context) ([]sqlc.ApiKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []sqlc.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]sqlc.ApiKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []sqlc.ApiKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.ApiKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type Store_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) ListAPIKeys(ctx interface{}) *Store_ListAPIKeys_Call {
+	return &Store_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx)}
+}
+
+func (_c *Store_ListAPIKeys_Call) Run(run func(ctx context.Context)) *Store_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_ListAPIKeys_Call) Return(_a0 []sqlc.ApiKey, _a1 error) *Store_ListAPIKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListAPIKeys_Call) RunAndReturn(run func(context.Context) ([]sqlc.ApiKey, error)) *Store_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListClaimablePartitions provides a mock function with given fields: ctx, arg
+func (_m *Store) ListClaimablePartitions(ctx context.Context, arg sqlc.ListClaimablePartitionsParams) ([]sqlc.ListClaimablePartitionsRow, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListClaimablePartitions")
+	}
+
+	var r0 []sqlc.ListClaimablePartitionsRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ListClaimablePartitionsParams) ([]sqlc.ListClaimablePartitionsRow, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ListClaimablePartitionsParams) []sqlc.ListClaimablePartitionsRow); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.ListClaimablePartitionsRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.ListClaimablePartitionsParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListClaimablePartitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListClaimablePartitions'
+type Store_ListClaimablePartitions_Call struct {
+	*mock.Call
+}
+
+// ListClaimablePartitions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.ListClaimablePartitionsParams
+func (_e *Store_Expecter) ListClaimablePartitions(ctx interface{}, arg interface{}) *Store_ListClaimablePartitions_Call {
+	return &Store_ListClaimablePartitions_Call{Call: _e.mock.On("ListClaimablePartitions", ctx, arg)}
+}
+
+func (_c *Store_ListClaimablePartitions_Call) Run(run func(ctx context.Context, arg sqlc.ListClaimablePartitionsParams)) *Store_ListClaimablePartitions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.ListClaimablePartitionsParams))
+	})
+	return _c
+}
+
+func (_c *Store_ListClaimablePartitions_Call) Return(_a0 []sqlc.ListClaimablePartitionsRow, _a1 error) *Store_ListClaimablePartitions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListClaimablePartitions_Call) RunAndReturn(run func(context.Context, sqlc.ListClaimablePartitionsParams) ([]sqlc.ListClaimablePartitionsRow, error)) *Store_ListClaimablePartitions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListProcessingWebhooks provides a mock function with given fields: ctx
+func (_m *Store) ListProcessingWebhooks(ctx context.Context) ([]sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListProcessingWebhooks")
+	}
+
+	var r0 []sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]sqlc.WebhookEvent, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []sqlc.WebhookEvent); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.WebhookEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListProcessingWebhooks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProcessingWebhooks'
+type Store_ListProcessingWebhooks_Call struct {
+	*mock.Call
+}
+
+// ListProcessingWebhooks is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) ListProcessingWebhooks(ctx interface{}) *Store_ListProcessingWebhooks_Call {
+	return &Store_ListProcessingWebhooks_Call{Call: _e.mock.On("ListProcessingWebhooks", ctx)}
+}
+
+func (_c *Store_ListProcessingWebhooks_Call) Run(run func(ctx context.Context)) *Store_ListProcessingWebhooks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_ListProcessingWebhooks_Call) Return(_a0 []sqlc.WebhookEvent, _a1 error) *Store_ListProcessingWebhooks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListProcessingWebhooks_Call) RunAndReturn(run func(context.Context) ([]sqlc.WebhookEvent, error)) *Store_ListProcessingWebhooks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListQueueControls provides a mock function with given fields: ctx
+func (_m *Store) ListQueueControls(ctx context.Context) ([]sqlc.QueueControl, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListQueueControls")
+	}
+
+	var r0 []sqlc.QueueControl
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]sqlc.QueueControl, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []sqlc.QueueControl); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.QueueControl)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListQueueControls_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListQueueControls'
+type Store_ListQueueControls_Call struct {
+	*mock.Call
+}
+
+// ListQueueControls is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) ListQueueControls(ctx interface{}) *Store_ListQueueControls_Call {
+	return &Store_ListQueueControls_Call{Call: _e.mock.On("ListQueueControls", ctx)}
+}
+
+func (_c *Store_ListQueueControls_Call) Run(run func(ctx context.Context)) *Store_ListQueueControls_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_ListQueueControls_Call) Return(_a0 []sqlc.QueueControl, _a1 error) *Store_ListQueueControls_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListQueueControls_Call) RunAndReturn(run func(context.Context) ([]sqlc.QueueControl, error)) *Store_ListQueueControls_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListWebhookAttempts provides a mock function with given fields: ctx, webhookEventID
+func (_m *Store) ListWebhookAttempts(ctx context.Context, webhookEventID uuid.UUID) ([]sqlc.WebhookAttempt, error) {
+	ret := _m.Called(ctx, webhookEventID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookAttempts")
+	}
+
+	var r0 []sqlc.WebhookAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]sqlc.WebhookAttempt, error)); ok {
+		return rf(ctx, webhookEventID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []sqlc.WebhookAttempt); ok {
+		r0 = rf(ctx, webhookEventID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.WebhookAttempt)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, webhookEventID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListWebhookAttempts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWebhookAttempts'
+type Store_ListWebhookAttempts_Call struct {
+	*mock.Call
+}
+
+// ListWebhookAttempts is a helper method to define mock.On call
+//   - ctx context.Context
+//   - webhookEventID uuid.UUID
+func (_e *Store_Expecter) ListWebhookAttempts(ctx interface{}, webhookEventID interface{}) *Store_ListWebhookAttempts_Call {
+	return &Store_ListWebhookAttempts_Call{Call: _e.mock.On("ListWebhookAttempts", ctx, webhookEventID)}
+}
+
+func (_c *Store_ListWebhookAttempts_Call) Run(run func(ctx context.Context, webhookEventID uuid.UUID)) *Store_ListWebhookAttempts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Store_ListWebhookAttempts_Call) Return(_a0 []sqlc.WebhookAttempt, _a1 error) *Store_ListWebhookAttempts_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListWebhookAttempts_Call) RunAndReturn(run func(context.Context, uuid.UUID) ([]sqlc.WebhookAttempt, error)) *Store_ListWebhookAttempts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListWorkers provides a mock function with given fields: ctx
+func (_m *Store) ListWorkers(ctx context.Context) ([]sqlc.Worker, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWorkers")
+	}
+
+	var r0 []sqlc.Worker
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]sqlc.Worker, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []sqlc.Worker); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.Worker)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ListWorkers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListWorkers'
+type Store_ListWorkers_Call struct {
+	*mock.Call
+}
+
+// ListWorkers is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) ListWorkers(ctx interface{}) *Store_ListWorkers_Call {
+	return &Store_ListWorkers_Call{Call: _e.mock.On("ListWorkers", ctx)}
+}
+
+func (_c *Store_ListWorkers_Call) Run(run func(ctx context.Context)) *Store_ListWorkers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_ListWorkers_Call) Return(_a0 []sqlc.Worker, _a1 error) *Store_ListWorkers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ListWorkers_Call) RunAndReturn(run func(context.Context) ([]sqlc.Worker, error)) *Store_ListWorkers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkWebhookDone provides a mock function with given fields: ctx, arg
+func (_m *Store) MarkWebhookDone(ctx context.Context, arg sqlc.MarkWebhookDoneParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkWebhookDone")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.MarkWebhookDoneParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.MarkWebhookDoneParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.MarkWebhookDoneParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_MarkWebhookDone_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkWebhookDone'
+type Store_MarkWebhookDone_Call struct {
+	*mock.Call
+}
+
+// MarkWebhookDone is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.MarkWebhookDoneParams
+func (_e *Store_Expecter) MarkWebhookDone(ctx interface{}, arg interface{}) *Store_MarkWebhookDone_Call {
+	return &Store_MarkWebhookDone_Call{Call: _e.mock.On("MarkWebhookDone", ctx, arg)}
+}
+
+func (_c *Store_MarkWebhookDone_Call) Run(run func(ctx context.Context, arg sqlc.MarkWebhookDoneParams)) *Store_MarkWebhookDone_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.MarkWebhookDoneParams))
+	})
+	return _c
+}
+
+func (_c *Store_MarkWebhookDone_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_MarkWebhookDone_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_MarkWebhookDone_Call) RunAndReturn(run func(context.Context, sqlc.MarkWebhookDoneParams) (sqlc.WebhookEvent, error)) *Store_MarkWebhookDone_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkWebhookFailed provides a mock function with given fields: ctx, arg
+func (_m *Store) MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkWebhookFailed")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.MarkWebhookFailedParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.MarkWebhookFailedParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_MarkWebhookFailed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkWebhookFailed'
+type Store_MarkWebhookFailed_Call struct {
+	*mock.Call
+}
+
+// MarkWebhookFailed is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.MarkWebhookFailedParams
+func (_e *Store_Expecter) MarkWebhookFailed(ctx interface{}, arg interface{}) *Store_MarkWebhookFailed_Call {
+	return &Store_MarkWebhookFailed_Call{Call: _e.mock.On("MarkWebhookFailed", ctx, arg)}
+}
+
+func (_c *Store_MarkWebhookFailed_Call) Run(run func(ctx context.Context, arg sqlc.MarkWebhookFailedParams)) *Store_MarkWebhookFailed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.MarkWebhookFailedParams))
+	})
+	return _c
+}
+
+func (_c *Store_MarkWebhookFailed_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_MarkWebhookFailed_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_MarkWebhookFailed_Call) RunAndReturn(run func(context.Context, sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error)) *Store_MarkWebhookFailed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Ping provides a mock function with given fields: ctx
+func (_m *Store) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ping")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store_Ping_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ping'
+type Store_Ping_Call struct {
+	*mock.Call
+}
+
+// Ping is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) Ping(ctx interface{}) *Store_Ping_Call {
+	return &Store_Ping_Call{Call: _e.mock.On("Ping", ctx)}
+}
+
+func (_c *Store_Ping_Call) Run(run func(ctx context.Context)) *Store_Ping_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_Ping_Call) Return(_a0 error) *Store_Ping_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_Ping_Call) RunAndReturn(run func(context.Context) error) *Store_Ping_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReclaimExpiredWebhooks provides a mock function with given fields: ctx, leaseSeconds
+func (_m *Store) ReclaimExpiredWebhooks(ctx context.Context, leaseSeconds float64) ([]sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, leaseSeconds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReclaimExpiredWebhooks")
+	}
+
+	var r0 []sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, float64) ([]sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, leaseSeconds)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, float64) []sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, leaseSeconds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sqlc.WebhookEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, float64) error); ok {
+		r1 = rf(ctx, leaseSeconds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ReclaimExpiredWebhooks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReclaimExpiredWebhooks'
+type Store_ReclaimExpiredWebhooks_Call struct {
+	*mock.Call
+}
+
+// ReclaimExpiredWebhooks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - leaseSeconds float64
+func (_e *Store_Expecter) ReclaimExpiredWebhooks(ctx interface{}, leaseSeconds interface{}) *Store_ReclaimExpiredWebhooks_Call {
+	return &Store_ReclaimExpiredWebhooks_Call{Call: _e.mock.On("ReclaimExpiredWebhooks", ctx, leaseSeconds)}
+}
+
+func (_c *Store_ReclaimExpiredWebhooks_Call) Run(run func(ctx context.Context, leaseSeconds float64)) *Store_ReclaimExpiredWebhooks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(float64))
+	})
+	return _c
+}
+
+func (_c *Store_ReclaimExpiredWebhooks_Call) Return(_a0 []sqlc.WebhookEvent, _a1 error) *Store_ReclaimExpiredWebhooks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ReclaimExpiredWebhooks_Call) RunAndReturn(run func(context.Context, float64) ([]sqlc.WebhookEvent, error)) *Store_ReclaimExpiredWebhooks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RegisterWorker provides a mock function with given fields: ctx, arg
+func (_m *Store) RegisterWorker(ctx context.Context, arg sqlc.RegisterWorkerParams) (sqlc.Worker, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterWorker")
+	}
+
+	var r0 sqlc.Worker
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RegisterWorkerParams) (sqlc.Worker, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RegisterWorkerParams) sqlc.Worker); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.Worker)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.RegisterWorkerParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_RegisterWorker_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RegisterWorker'
+type Store_RegisterWorker_Call struct {
+	*mock.Call
+}
+
+// RegisterWorker is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.RegisterWorkerParams
+func (_e *Store_Expecter) RegisterWorker(ctx interface{}, arg interface{}) *Store_RegisterWorker_Call {
+	return &Store_RegisterWorker_Call{Call: _e.mock.On("RegisterWorker", ctx, arg)}
+}
+
+func (_c *Store_RegisterWorker_Call) Run(run func(ctx context.Context, arg sqlc.RegisterWorkerParams)) *Store_RegisterWorker_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.RegisterWorkerParams))
+	})
+	return _c
+}
+
+func (_c *Store_RegisterWorker_Call) Return(_a0 sqlc.Worker, _a1 error) *Store_RegisterWorker_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_RegisterWorker_Call) RunAndReturn(run func(context.Context, sqlc.RegisterWorkerParams) (sqlc.Worker, error)) *Store_RegisterWorker_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseWebhook provides a mock function with given fields: ctx, arg
+func (_m *Store) ReleaseWebhook(ctx context.Context, arg sqlc.ReleaseWebhookParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseWebhook")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ReleaseWebhookParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.ReleaseWebhookParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.ReleaseWebhookParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_ReleaseWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseWebhook'
+type Store_ReleaseWebhook_Call struct {
+	*mock.Call
+}
+
+// ReleaseWebhook is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.ReleaseWebhookParams
+func (_e *Store_Expecter) ReleaseWebhook(ctx interface{}, arg interface{}) *Store_ReleaseWebhook_Call {
+	return &Store_ReleaseWebhook_Call{Call: _e.mock.On("ReleaseWebhook", ctx, arg)}
+}
+
+func (_c *Store_ReleaseWebhook_Call) Run(run func(ctx context.Context, arg sqlc.ReleaseWebhookParams)) *Store_ReleaseWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.ReleaseWebhookParams))
+	})
+	return _c
+}
+
+func (_c *Store_ReleaseWebhook_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_ReleaseWebhook_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_ReleaseWebhook_Call) RunAndReturn(run func(context.Context, sqlc.ReleaseWebhookParams) (sqlc.WebhookEvent, error)) *Store_ReleaseWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RenewWebhookLease provides a mock function with given fields: ctx, arg
+func (_m *Store) RenewWebhookLease(ctx context.Context, arg sqlc.RenewWebhookLeaseParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenewWebhookLease")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RenewWebhookLeaseParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RenewWebhookLeaseParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.RenewWebhookLeaseParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_RenewWebhookLease_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RenewWebhookLease'
+type Store_RenewWebhookLease_Call struct {
+	*mock.Call
+}
+
+// RenewWebhookLease is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.RenewWebhookLeaseParams
+func (_e *Store_Expecter) RenewWebhookLease(ctx interface{}, arg interface{}) *Store_RenewWebhookLease_Call {
+	return &Store_RenewWebhookLease_Call{Call: _e.mock.On("RenewWebhookLease", ctx, arg)}
+}
+
+func (_c *Store_RenewWebhookLease_Call) Run(run func(ctx context.Context, arg sqlc.RenewWebhookLeaseParams)) *Store_RenewWebhookLease_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.RenewWebhookLeaseParams))
+	})
+	return _c
+}
+
+func (_c *Store_RenewWebhookLease_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_RenewWebhookLease_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_RenewWebhookLease_Call) RunAndReturn(run func(context.Context, sqlc.RenewWebhookLeaseParams) (sqlc.WebhookEvent, error)) *Store_RenewWebhookLease_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetryWebhook provides a mock function with given fields: ctx, arg
+func (_m *Store) RetryWebhook(ctx context.Context, arg sqlc.RetryWebhookParams) (sqlc.WebhookEvent, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetryWebhook")
+	}
+
+	var r0 sqlc.WebhookEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RetryWebhookParams) (sqlc.WebhookEvent, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.RetryWebhookParams) sqlc.WebhookEvent); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.WebhookEvent)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.RetryWebhookParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_RetryWebhook_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetryWebhook'
+type Store_RetryWebhook_Call struct {
+	*mock.Call
+}
+
+// RetryWebhook is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.RetryWebhookParams
+func (_e *Store_Expecter) RetryWebhook(ctx interface{}, arg interface{}) *Store_RetryWebhook_Call {
+	return &Store_RetryWebhook_Call{Call: _e.mock.On("RetryWebhook", ctx, arg)}
+}
+
+func (_c *Store_RetryWebhook_Call) Run(run func(ctx context.Context, arg sqlc.RetryWebhookParams)) *Store_RetryWebhook_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.RetryWebhookParams))
+	})
+	return _c
+}
+
+func (_c *Store_RetryWebhook_Call) Return(_a0 sqlc.WebhookEvent, _a1 error) *Store_RetryWebhook_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_RetryWebhook_Call) RunAndReturn(run func(context.Context, sqlc.RetryWebhookParams) (sqlc.WebhookEvent, error)) *Store_RetryWebhook_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id
+func (_m *Store) RevokeAPIKey(ctx context.Context, id uuid.UUID) (sqlc.ApiKey, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 sqlc.ApiKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (sqlc.ApiKey, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) sqlc.ApiKey); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(sqlc.ApiKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type Store_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Store_Expecter) RevokeAPIKey(ctx interface{}, id interface{}) *Store_RevokeAPIKey_Call {
+	return &Store_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, id)}
+}
+
+func (_c *Store_RevokeAPIKey_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Store_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Store_RevokeAPIKey_Call) Return(_a0 sqlc.ApiKey, _a1 error) *Store_RevokeAPIKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_RevokeAPIKey_Call) RunAndReturn(run func(context.Context, uuid.UUID) (sqlc.ApiKey, error)) *Store_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SchemaVersion provides a mock function with given fields: ctx
+func (_m *Store) SchemaVersion(ctx context.Context) (uint, bool, bool, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SchemaVersion")
+	}
+
+	var r0 uint
+	var r1 bool
+	var r2 bool
+	var r3 error
+	if rf, ok := ret.Get(0).(func(context.Context) (uint, bool, bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) bool); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context) bool); ok {
+		r2 = rf(ctx)
+	} else {
+		r2 = ret.Get(2).(bool)
+	}
+
+	if rf, ok := ret.Get(3).(func(context.Context) error); ok {
+		r3 = rf(ctx)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// Store_SchemaVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SchemaVersion'
+type Store_SchemaVersion_Call struct {
+	*mock.Call
+}
+
+// SchemaVersion is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Store_Expecter) SchemaVersion(ctx interface{}) *Store_SchemaVersion_Call {
+	return &Store_SchemaVersion_Call{Call: _e.mock.On("SchemaVersion", ctx)}
+}
+
+func (_c *Store_SchemaVersion_Call) Run(run func(ctx context.Context)) *Store_SchemaVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Store_SchemaVersion_Call) Return(version uint, dirty bool, ok bool, err error) *Store_SchemaVersion_Call {
+	_c.Call.Return(version, dirty, ok, err)
+	return _c
+}
+
+func (_c *Store_SchemaVersion_Call) RunAndReturn(run func(context.Context) (uint, bool, bool, error)) *Store_SchemaVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetQueuePaused provides a mock function with given fields: ctx, arg
+func (_m *Store) SetQueuePaused(ctx context.Context, arg sqlc.SetQueuePausedParams) (sqlc.QueueControl, error) {
+	ret := _m.Called(ctx, arg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetQueuePaused")
+	}
+
+	var r0 sqlc.QueueControl
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.SetQueuePausedParams) (sqlc.QueueControl, error)); ok {
+		return rf(ctx, arg)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, sqlc.SetQueuePausedParams) sqlc.QueueControl); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(sqlc.QueueControl)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, sqlc.SetQueuePausedParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_SetQueuePaused_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetQueuePaused'
+type Store_SetQueuePaused_Call struct {
+	*mock.Call
+}
+
+// SetQueuePaused is a helper method to define mock.On call
+//   - ctx context.Context
+//   - arg sqlc.SetQueuePausedParams
+func (_e *Store_Expecter) SetQueuePaused(ctx interface{}, arg interface{}) *Store_SetQueuePaused_Call {
+	return &Store_SetQueuePaused_Call{Call: _e.mock.On("SetQueuePaused", ctx, arg)}
+}
+
+func (_c *Store_SetQueuePaused_Call) Run(run func(ctx context.Context, arg sqlc.SetQueuePausedParams)) *Store_SetQueuePaused_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(sqlc.SetQueuePausedParams))
+	})
+	return _c
+}
+
+func (_c *Store_SetQueuePaused_Call) Return(_a0 sqlc.QueueControl, _a1 error) *Store_SetQueuePaused_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_SetQueuePaused_Call) RunAndReturn(run func(context.Context, sqlc.SetQueuePausedParams) (sqlc.QueueControl, error)) *Store_SetQueuePaused_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StopWorker provides a mock function with given fields: ctx, id
+func (_m *Store) StopWorker(ctx context.Context, id uuid.UUID) (sqlc.Worker, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StopWorker")
+	}
+
+	var r0 sqlc.Worker
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (sqlc.Worker, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) sqlc.Worker); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(sqlc.Worker)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Store_StopWorker_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StopWorker'
+type Store_StopWorker_Call struct {
+	*mock.Call
+}
+
+// StopWorker is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Store_Expecter) StopWorker(ctx interface{}, id interface{}) *Store_StopWorker_Call {
+	return &Store_StopWorker_Call{Call: _e.mock.On("StopWorker", ctx, id)}
+}
+
+func (_c *Store_StopWorker_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Store_StopWorker_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Store_StopWorker_Call) Return(_a0 sqlc.Worker, _a1 error) *Store_StopWorker_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Store_StopWorker_Call) RunAndReturn(run func(context.Context, uuid.UUID) (sqlc.Worker, error)) *Store_StopWorker_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id
+func (_m *Store) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Store_TouchAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TouchAPIKey'
+type Store_TouchAPIKey_Call struct {
+	*mock.Call
+}
+
+// TouchAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *Store_Expecter) TouchAPIKey(ctx interface{}, id interface{}) *Store_TouchAPIKey_Call {
+	return &Store_TouchAPIKey_Call{Call: _e.mock.On("TouchAPIKey", ctx, id)}
+}
+
+func (_c *Store_TouchAPIKey_Call) Run(run func(ctx context.Context, id uuid.UUID)) *Store_TouchAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *Store_TouchAPIKey_Call) Return(_a0 error) *Store_TouchAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Store_TouchAPIKey_Call) RunAndReturn(run func(context.Context, uuid.UUID) error) *Store_TouchAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Store {
+	mock := &Store{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
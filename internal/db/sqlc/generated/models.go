@@ -9,15 +9,63 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID          `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	KeyHash    string             `json:"key_hash"`
+	TenantID   string             `json:"tenant_id"`
+	Scopes     []string           `json:"scopes"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	LastUsedAt pgtype.Timestamp   `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamp   `json:"revoked_at"`
+}
+
+type QueueControl struct {
+	Scope     string             `json:"scope"`
+	Paused    bool               `json:"paused"`
+	Reason    *string            `json:"reason"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookAttempt struct {
+	ID             uuid.UUID          `json:"id"`
+	WebhookEventID uuid.UUID          `json:"webhook_event_id"`
+	Attempt        int32              `json:"attempt"`
+	WorkerID       string             `json:"worker_id"`
+	Host           string             `json:"host"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	FinishedAt     pgtype.Timestamptz `json:"finished_at"`
+	Outcome        string             `json:"outcome"`
+	Error          *string            `json:"error"`
+	DurationMs     int64              `json:"duration_ms"`
+	Reason         *string            `json:"reason"`
+}
+
 type WebhookEvent struct {
+	ID            uuid.UUID          `json:"id"`
+	EventID       string             `json:"event_id"`
+	Type          *string            `json:"type"`
+	Payload       []byte             `json:"payload"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	LastError     *string            `json:"last_error"`
+	ReceivedAt    pgtype.Timestamptz `json:"received_at"`
+	ProcessedAt   pgtype.Timestamp   `json:"processed_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	ClaimedBy     pgtype.UUID        `json:"claimed_by"`
+	ClaimedAt     pgtype.Timestamp   `json:"claimed_at"`
+	TenantID      string             `json:"tenant_id"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+type Worker struct {
 	ID          uuid.UUID          `json:"id"`
-	EventID     string             `json:"event_id"`
-	Type        *string            `json:"type"`
-	Payload     []byte             `json:"payload"`
-	Status      string             `json:"status"`
-	Attempts    int32              `json:"attempts"`
-	LastError   *string            `json:"last_error"`
-	ReceivedAt  pgtype.Timestamptz `json:"received_at"`
-	ProcessedAt pgtype.Timestamp   `json:"processed_at"`
-	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	Host        string             `json:"host"`
+	Pid         int32              `json:"pid"`
+	Version     string             `json:"version"`
+	Concurrency int32              `json:"concurrency"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	StoppedAt   pgtype.Timestamp   `json:"stopped_at"`
 }
@@ -11,10 +11,50 @@ import (
 )
 
 type Querier interface {
-	ClaimNextWebhook(ctx context.Context) (WebhookEvent, error)
+	// Claims the oldest claimable event. With partition_by set to 'tenant' or
+	// 'type' the claim is restricted to that partition, which is how the worker
+	// pool's fair schedulers pick whose turn it is; an empty partition_by is
+	// plain FIFO across everything.
+	ClaimNextWebhook(ctx context.Context, arg ClaimNextWebhookParams) (WebhookEvent, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (WebhookEvent, error)
-	MarkWebhookDone(ctx context.Context, id uuid.UUID) (WebhookEvent, error)
+	CreateWebhookAttempt(ctx context.Context, arg CreateWebhookAttemptParams) (WebhookAttempt, error)
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	// Backlog waiting for a worker: events a worker could claim right now.
+	// Paused types and retries that are not due yet are left out so a
+	// deliberate pause or a backoff does not trigger ingest backpressure.
+	GetQueueStats(ctx context.Context) (GetQueueStatsRow, error)
+	// Per-tenant share of the GetQueueStats backlog, for ingest quotas.
+	GetTenantQueueStats(ctx context.Context) ([]GetTenantQueueStatsRow, error)
+	GetWebhookByEventID(ctx context.Context, arg GetWebhookByEventIDParams) (WebhookEvent, error)
+	HeartbeatWorker(ctx context.Context, arg HeartbeatWorkerParams) (Worker, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	// Partitions (tenants or types, per partition_by) that have an event
+	// ClaimNextWebhook could claim right now, with how many.
+	ListClaimablePartitions(ctx context.Context, arg ListClaimablePartitionsParams) ([]ListClaimablePartitionsRow, error)
+	ListProcessingWebhooks(ctx context.Context) ([]WebhookEvent, error)
+	ListQueueControls(ctx context.Context) ([]QueueControl, error)
+	ListWebhookAttempts(ctx context.Context, webhookEventID uuid.UUID) ([]WebhookAttempt, error)
+	ListWorkers(ctx context.Context) ([]Worker, error)
+	// The settle queries are fenced on the claim like RenewWebhookLease: no row
+	// comes back once the lease has been lost and the event reclaimed.
+	MarkWebhookDone(ctx context.Context, arg MarkWebhookDoneParams) (WebhookEvent, error)
 	MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) (WebhookEvent, error)
+	// Returns processing events to the queue when the lease on their claim has
+	// run out, or when the pool holding them has stopped, missed its
+	// heartbeats or been pruned.
+	ReclaimExpiredWebhooks(ctx context.Context, leaseSeconds float64) ([]WebhookEvent, error)
+	RegisterWorker(ctx context.Context, arg RegisterWorkerParams) (Worker, error)
+	// Hands a claimed event back without counting the attempt.
+	ReleaseWebhook(ctx context.Context, arg ReleaseWebhookParams) (WebhookEvent, error)
+	// Extends the lease the claiming pool holds on an event it is processing.
+	// No row comes back once the event has been settled or reclaimed.
+	RenewWebhookLease(ctx context.Context, arg RenewWebhookLeaseParams) (WebhookEvent, error)
+	RetryWebhook(ctx context.Context, arg RetryWebhookParams) (WebhookEvent, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (ApiKey, error)
+	SetQueuePaused(ctx context.Context, arg SetQueuePausedParams) (QueueControl, error)
+	StopWorker(ctx context.Context, id uuid.UUID) (Worker, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
 }
 
 var _ Querier = (*Queries)(nil)
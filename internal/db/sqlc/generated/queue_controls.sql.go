@@ -0,0 +1,66 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: queue_controls.sql
+
+package sqlc
+
+import (
+	"context"
+)
+
+const listQueueControls = `-- name: ListQueueControls :many
+SELECT scope, paused, reason, updated_at FROM queue_controls
+ORDER BY scope ASC
+`
+
+func (q *Queries) ListQueueControls(ctx context.Context) ([]QueueControl, error) {
+	rows, err := q.db.Query(ctx, listQueueControls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QueueControl{}
+	for rows.Next() {
+		var i QueueControl
+		if err := rows.Scan(
+			&i.Scope,
+			&i.Paused,
+			&i.Reason,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setQueuePaused = `-- name: SetQueuePaused :one
+INSERT INTO queue_controls (scope, paused, reason, updated_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+ON CONFLICT (scope) DO UPDATE
+SET paused = EXCLUDED.paused, reason = EXCLUDED.reason, updated_at = EXCLUDED.updated_at
+RETURNING scope, paused, reason, updated_at
+`
+
+type SetQueuePausedParams struct {
+	Scope  string  `json:"scope"`
+	Paused bool    `json:"paused"`
+	Reason *string `json:"reason"`
+}
+
+func (q *Queries) SetQueuePaused(ctx context.Context, arg SetQueuePausedParams) (QueueControl, error) {
+	row := q.db.QueryRow(ctx, setQueuePaused, arg.Scope, arg.Paused, arg.Reason)
+	var i QueueControl
+	err := row.Scan(
+		&i.Scope,
+		&i.Paused,
+		&i.Reason,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,101 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: webhook_attempts.sql
+
+package sqlc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createWebhookAttempt = `-- name: CreateWebhookAttempt :one
+INSERT INTO webhook_attempts (
+  webhook_event_id, attempt, worker_id, host, started_at, finished_at, outcome, reason, error, duration_ms
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, webhook_event_id, attempt, worker_id, host, started_at, finished_at, outcome, error, duration_ms, reason
+`
+
+type CreateWebhookAttemptParams struct {
+	WebhookEventID uuid.UUID          `json:"webhook_event_id"`
+	Attempt        int32              `json:"attempt"`
+	WorkerID       string             `json:"worker_id"`
+	Host           string             `json:"host"`
+	StartedAt      pgtype.Timestamptz `json:"started_at"`
+	FinishedAt     pgtype.Timestamptz `json:"finished_at"`
+	Outcome        string             `json:"outcome"`
+	Reason         *string            `json:"reason"`
+	Error          *string            `json:"error"`
+	DurationMs     int64              `json:"duration_ms"`
+}
+
+func (q *Queries) CreateWebhookAttempt(ctx context.Context, arg CreateWebhookAttemptParams) (WebhookAttempt, error) {
+	row := q.db.QueryRow(ctx, createWebhookAttempt,
+		arg.WebhookEventID,
+		arg.Attempt,
+		arg.WorkerID,
+		arg.Host,
+		arg.StartedAt,
+		arg.FinishedAt,
+		arg.Outcome,
+		arg.Reason,
+		arg.Error,
+		arg.DurationMs,
+	)
+	var i WebhookAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookEventID,
+		&i.Attempt,
+		&i.WorkerID,
+		&i.Host,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Outcome,
+		&i.Error,
+		&i.DurationMs,
+		&i.Reason,
+	)
+	return i, err
+}
+
+const listWebhookAttempts = `-- name: ListWebhookAttempts :many
+SELECT id, webhook_event_id, attempt, worker_id, host, started_at, finished_at, outcome, error, duration_ms, reason FROM webhook_attempts
+WHERE webhook_event_id = $1
+ORDER BY attempt ASC, started_at ASC
+`
+
+func (q *Queries) ListWebhookAttempts(ctx context.Context, webhookEventID uuid.UUID) ([]WebhookAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookAttempts, webhookEventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookAttempt{}
+	for rows.Next() {
+		var i WebhookAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookEventID,
+			&i.Attempt,
+			&i.WorkerID,
+			&i.Host,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Outcome,
+			&i.Error,
+			&i.DurationMs,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -9,23 +9,51 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const claimNextWebhook = `-- name: ClaimNextWebhook :one
 UPDATE webhook_events
-SET status = 'processing', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+SET status = 'processing', attempts = attempts + 1,
+    claimed_by = $1::uuid, claimed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = (
   SELECT id FROM webhook_events
-  WHERE status = 'received'
+  WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+    AND COALESCE(type, '') <> ALL(COALESCE($2::text[], '{}'))
+    AND NOT EXISTS (
+      SELECT 1 FROM queue_controls qc
+      WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+    )
+    AND CASE $3::text
+      WHEN 'tenant' THEN tenant_id = $4::text
+      WHEN 'type' THEN COALESCE(type, '') = $4::text
+      ELSE TRUE
+    END
   ORDER BY received_at ASC
   LIMIT 1
   FOR UPDATE SKIP LOCKED
 )
-RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
 `
 
-func (q *Queries) ClaimNextWebhook(ctx context.Context) (WebhookEvent, error) {
-	row := q.db.QueryRow(ctx, claimNextWebhook)
+type ClaimNextWebhookParams struct {
+	ClaimedBy     uuid.UUID `json:"claimed_by"`
+	ExcludedTypes []string  `json:"excluded_types"`
+	PartitionBy   string    `json:"partition_by"`
+	Partition     string    `json:"partition"`
+}
+
+// Claims the oldest claimable event. With partition_by set to 'tenant' or
+// 'type' the claim is restricted to that partition, which is how the worker
+// pool's fair schedulers pick whose turn it is; an empty partition_by is
+// plain FIFO across everything.
+func (q *Queries) ClaimNextWebhook(ctx context.Context, arg ClaimNextWebhookParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, claimNextWebhook,
+		arg.ClaimedBy,
+		arg.ExcludedTypes,
+		arg.PartitionBy,
+		arg.Partition,
+	)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -38,23 +66,33 @@ func (q *Queries) ClaimNextWebhook(ctx context.Context) (WebhookEvent, error) {
 		&i.ReceivedAt,
 		&i.ProcessedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
 
 const createWebhook = `-- name: CreateWebhook :one
-INSERT INTO webhook_events (event_id, type, payload) VALUES ($1, $2, $3)
-RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at
+INSERT INTO webhook_events (tenant_id, event_id, type, payload) VALUES ($1, $2, $3, $4)
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
 `
 
 type CreateWebhookParams struct {
-	EventID string  `json:"event_id"`
-	Type    *string `json:"type"`
-	Payload []byte  `json:"payload"`
+	TenantID string  `json:"tenant_id"`
+	EventID  string  `json:"event_id"`
+	Type     *string `json:"type"`
+	Payload  []byte  `json:"payload"`
 }
 
 func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (WebhookEvent, error) {
-	row := q.db.QueryRow(ctx, createWebhook, arg.EventID, arg.Type, arg.Payload)
+	row := q.db.QueryRow(ctx, createWebhook,
+		arg.TenantID,
+		arg.EventID,
+		arg.Type,
+		arg.Payload,
+	)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -67,19 +105,174 @@ func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (W
 		&i.ReceivedAt,
 		&i.ProcessedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
 
+const getQueueStats = `-- name: GetQueueStats :one
+SELECT
+  COUNT(*) AS depth,
+  COALESCE(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - MIN(received_at)), 0)::float8 AS oldest_age_seconds
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  )
+`
+
+type GetQueueStatsRow struct {
+	Depth            int64   `json:"depth"`
+	OldestAgeSeconds float64 `json:"oldest_age_seconds"`
+}
+
+// Backlog waiting for a worker: events a worker could claim right now.
+// Paused types and retries that are not due yet are left out so a
+// deliberate pause or a backoff does not trigger ingest backpressure.
+func (q *Queries) GetQueueStats(ctx context.Context) (GetQueueStatsRow, error) {
+	row := q.db.QueryRow(ctx, getQueueStats)
+	var i GetQueueStatsRow
+	err := row.Scan(&i.Depth, &i.OldestAgeSeconds)
+	return i, err
+}
+
+const getTenantQueueStats = `-- name: GetTenantQueueStats :many
+SELECT tenant_id, COUNT(*) AS depth
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  )
+GROUP BY tenant_id
+ORDER BY tenant_id
+`
+
+type GetTenantQueueStatsRow struct {
+	TenantID string `json:"tenant_id"`
+	Depth    int64  `json:"depth"`
+}
+
+// Per-tenant share of the GetQueueStats backlog, for ingest quotas.
+func (q *Queries) GetTenantQueueStats(ctx context.Context) ([]GetTenantQueueStatsRow, error) {
+	rows, err := q.db.Query(ctx, getTenantQueueStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetTenantQueueStatsRow{}
+	for rows.Next() {
+		var i GetTenantQueueStatsRow
+		if err := rows.Scan(&i.TenantID, &i.Depth); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookByEventID = `-- name: GetWebhookByEventID :one
+SELECT id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at FROM webhook_events
+WHERE tenant_id = $1 AND event_id = $2
+`
+
+type GetWebhookByEventIDParams struct {
+	TenantID string `json:"tenant_id"`
+	EventID  string `json:"event_id"`
+}
+
+func (q *Queries) GetWebhookByEventID(ctx context.Context, arg GetWebhookByEventIDParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, getWebhookByEventID, arg.TenantID, arg.EventID)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const listClaimablePartitions = `-- name: ListClaimablePartitions :many
+SELECT
+  (CASE $1::text WHEN 'tenant' THEN tenant_id ELSE COALESCE(type, '') END)::text AS partition,
+  COUNT(*) AS depth
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND COALESCE(type, '') <> ALL(COALESCE($2::text[], '{}'))
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  )
+GROUP BY 1
+ORDER BY 1
+`
+
+type ListClaimablePartitionsParams struct {
+	PartitionBy   string   `json:"partition_by"`
+	ExcludedTypes []string `json:"excluded_types"`
+}
+
+type ListClaimablePartitionsRow struct {
+	Partition string `json:"partition"`
+	Depth     int64  `json:"depth"`
+}
+
+// Partitions (tenants or types, per partition_by) that have an event
+// ClaimNextWebhook could claim right now, with how many.
+func (q *Queries) ListClaimablePartitions(ctx context.Context, arg ListClaimablePartitionsParams) ([]ListClaimablePartitionsRow, error) {
+	rows, err := q.db.Query(ctx, listClaimablePartitions, arg.PartitionBy, arg.ExcludedTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListClaimablePartitionsRow{}
+	for rows.Next() {
+		var i ListClaimablePartitionsRow
+		if err := rows.Scan(&i.Partition, &i.Depth); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markWebhookDone = `-- name: MarkWebhookDone :one
 UPDATE webhook_events
 SET status = 'done', processed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at
+WHERE id = $1 AND status = 'processing' AND claimed_by = $2::uuid
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
 `
 
-func (q *Queries) MarkWebhookDone(ctx context.Context, id uuid.UUID) (WebhookEvent, error) {
-	row := q.db.QueryRow(ctx, markWebhookDone, id)
+type MarkWebhookDoneParams struct {
+	ID        uuid.UUID `json:"id"`
+	ClaimedBy uuid.UUID `json:"claimed_by"`
+}
+
+// The settle queries are fenced on the claim like RenewWebhookLease: no row
+// comes back once the lease has been lost and the event reclaimed.
+func (q *Queries) MarkWebhookDone(ctx context.Context, arg MarkWebhookDoneParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, markWebhookDone, arg.ID, arg.ClaimedBy)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -92,24 +285,198 @@ func (q *Queries) MarkWebhookDone(ctx context.Context, id uuid.UUID) (WebhookEve
 		&i.ReceivedAt,
 		&i.ProcessedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
 
 const markWebhookFailed = `-- name: MarkWebhookFailed :one
 UPDATE webhook_events
-SET status = 'failed', last_error = $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
-RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at
+SET status = 'failed', last_error = $1, updated_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND status = 'processing' AND claimed_by = $3::uuid
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
 `
 
 type MarkWebhookFailedParams struct {
-	ID        uuid.UUID `json:"id"`
 	LastError *string   `json:"last_error"`
+	ID        uuid.UUID `json:"id"`
+	ClaimedBy uuid.UUID `json:"claimed_by"`
 }
 
 func (q *Queries) MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) (WebhookEvent, error) {
-	row := q.db.QueryRow(ctx, markWebhookFailed, arg.ID, arg.LastError)
+	row := q.db.QueryRow(ctx, markWebhookFailed, arg.LastError, arg.ID, arg.ClaimedBy)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const reclaimExpiredWebhooks = `-- name: ReclaimExpiredWebhooks :many
+UPDATE webhook_events
+SET status = 'received', claimed_by = NULL, claimed_at = NULL,
+    last_error = 'claim expired before the attempt was settled',
+    next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE status = 'processing'
+  AND (
+    claimed_by IS NULL
+    OR claimed_at < CURRENT_TIMESTAMP - make_interval(secs => $1::float8)
+    OR EXISTS (
+      SELECT 1 FROM workers w
+      WHERE w.id = webhook_events.claimed_by
+        AND (w.stopped_at IS NOT NULL
+          OR w.heartbeat_at < CURRENT_TIMESTAMP - make_interval(secs => $1::float8))
+    )
+  )
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
+`
+
+// Returns processing events to the queue when the lease on their claim has
+// run out, or when the pool holding them has stopped, missed its
+// heartbeats or been pruned.
+func (q *Queries) ReclaimExpiredWebhooks(ctx context.Context, leaseSeconds float64) ([]WebhookEvent, error) {
+	rows, err := q.db.Query(ctx, reclaimExpiredWebhooks, leaseSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEvent{}
+	for rows.Next() {
+		var i WebhookEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.ReceivedAt,
+			&i.ProcessedAt,
+			&i.UpdatedAt,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.TenantID,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseWebhook = `-- name: ReleaseWebhook :one
+UPDATE webhook_events
+SET status = 'received', attempts = attempts - 1, claimed_by = NULL, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'processing' AND claimed_by = $2::uuid
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
+`
+
+type ReleaseWebhookParams struct {
+	ID        uuid.UUID `json:"id"`
+	ClaimedBy uuid.UUID `json:"claimed_by"`
+}
+
+// Hands a claimed event back without counting the attempt.
+func (q *Queries) ReleaseWebhook(ctx context.Context, arg ReleaseWebhookParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, releaseWebhook, arg.ID, arg.ClaimedBy)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const renewWebhookLease = `-- name: RenewWebhookLease :one
+UPDATE webhook_events
+SET claimed_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'processing' AND claimed_by = $2::uuid
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
+`
+
+type RenewWebhookLeaseParams struct {
+	ID        uuid.UUID `json:"id"`
+	ClaimedBy uuid.UUID `json:"claimed_by"`
+}
+
+// Extends the lease the claiming pool holds on an event it is processing.
+// No row comes back once the event has been settled or reclaimed.
+func (q *Queries) RenewWebhookLease(ctx context.Context, arg RenewWebhookLeaseParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, renewWebhookLease, arg.ID, arg.ClaimedBy)
+	var i WebhookEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.Type,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ReceivedAt,
+		&i.ProcessedAt,
+		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
+	)
+	return i, err
+}
+
+const retryWebhook = `-- name: RetryWebhook :one
+UPDATE webhook_events
+SET status = 'received', last_error = $1, next_attempt_at = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $3 AND status = 'processing' AND claimed_by = $4::uuid
+RETURNING id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at
+`
+
+type RetryWebhookParams struct {
+	LastError     *string            `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	ID            uuid.UUID          `json:"id"`
+	ClaimedBy     uuid.UUID          `json:"claimed_by"`
+}
+
+func (q *Queries) RetryWebhook(ctx context.Context, arg RetryWebhookParams) (WebhookEvent, error) {
+	row := q.db.QueryRow(ctx, retryWebhook,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+		arg.ClaimedBy,
+	)
 	var i WebhookEvent
 	err := row.Scan(
 		&i.ID,
@@ -122,6 +489,10 @@ func (q *Queries) MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedPa
 		&i.ReceivedAt,
 		&i.ProcessedAt,
 		&i.UpdatedAt,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.TenantID,
+		&i.NextAttemptAt,
 	)
 	return i, err
 }
@@ -0,0 +1,174 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: workers.sql
+
+package sqlc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const heartbeatWorker = `-- name: HeartbeatWorker :one
+UPDATE workers
+SET heartbeat_at = CURRENT_TIMESTAMP, concurrency = $2
+WHERE id = $1
+RETURNING id, host, pid, version, concurrency, started_at, heartbeat_at, stopped_at
+`
+
+type HeartbeatWorkerParams struct {
+	ID          uuid.UUID `json:"id"`
+	Concurrency int32     `json:"concurrency"`
+}
+
+func (q *Queries) HeartbeatWorker(ctx context.Context, arg HeartbeatWorkerParams) (Worker, error) {
+	row := q.db.QueryRow(ctx, heartbeatWorker, arg.ID, arg.Concurrency)
+	var i Worker
+	err := row.Scan(
+		&i.ID,
+		&i.Host,
+		&i.Pid,
+		&i.Version,
+		&i.Concurrency,
+		&i.StartedAt,
+		&i.HeartbeatAt,
+		&i.StoppedAt,
+	)
+	return i, err
+}
+
+const listProcessingWebhooks = `-- name: ListProcessingWebhooks :many
+SELECT id, event_id, type, payload, status, attempts, last_error, received_at, processed_at, updated_at, claimed_by, claimed_at, tenant_id, next_attempt_at FROM webhook_events
+WHERE status = 'processing' AND claimed_by IS NOT NULL
+ORDER BY claimed_at ASC
+`
+
+func (q *Queries) ListProcessingWebhooks(ctx context.Context) ([]WebhookEvent, error) {
+	rows, err := q.db.Query(ctx, listProcessingWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEvent{}
+	for rows.Next() {
+		var i WebhookEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.Type,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.ReceivedAt,
+			&i.ProcessedAt,
+			&i.UpdatedAt,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.TenantID,
+			&i.NextAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWorkers = `-- name: ListWorkers :many
+SELECT id, host, pid, version, concurrency, started_at, heartbeat_at, stopped_at FROM workers
+ORDER BY started_at DESC
+`
+
+func (q *Queries) ListWorkers(ctx context.Context) ([]Worker, error) {
+	rows, err := q.db.Query(ctx, listWorkers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Worker{}
+	for rows.Next() {
+		var i Worker
+		if err := rows.Scan(
+			&i.ID,
+			&i.Host,
+			&i.Pid,
+			&i.Version,
+			&i.Concurrency,
+			&i.StartedAt,
+			&i.HeartbeatAt,
+			&i.StoppedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const registerWorker = `-- name: RegisterWorker :one
+INSERT INTO workers (id, host, pid, version, concurrency)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, host, pid, version, concurrency, started_at, heartbeat_at, stopped_at
+`
+
+type RegisterWorkerParams struct {
+	ID          uuid.UUID `json:"id"`
+	Host        string    `json:"host"`
+	Pid         int32     `json:"pid"`
+	Version     string    `json:"version"`
+	Concurrency int32     `json:"concurrency"`
+}
+
+func (q *Queries) RegisterWorker(ctx context.Context, arg RegisterWorkerParams) (Worker, error) {
+	row := q.db.QueryRow(ctx, registerWorker,
+		arg.ID,
+		arg.Host,
+		arg.Pid,
+		arg.Version,
+		arg.Concurrency,
+	)
+	var i Worker
+	err := row.Scan(
+		&i.ID,
+		&i.Host,
+		&i.Pid,
+		&i.Version,
+		&i.Concurrency,
+		&i.StartedAt,
+		&i.HeartbeatAt,
+		&i.StoppedAt,
+	)
+	return i, err
+}
+
+const stopWorker = `-- name: StopWorker :one
+UPDATE workers
+SET stopped_at = CURRENT_TIMESTAMP, heartbeat_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, host, pid, version, concurrency, started_at, heartbeat_at, stopped_at
+`
+
+func (q *Queries) StopWorker(ctx context.Context, id uuid.UUID) (Worker, error) {
+	row := q.db.QueryRow(ctx, stopWorker, id)
+	var i Worker
+	err := row.Scan(
+		&i.ID,
+		&i.Host,
+		&i.Pid,
+		&i.Version,
+		&i.Concurrency,
+		&i.StartedAt,
+		&i.HeartbeatAt,
+		&i.StoppedAt,
+	)
+	return i, err
+}
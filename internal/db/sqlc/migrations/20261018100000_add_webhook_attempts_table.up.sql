@@ -0,0 +1,21 @@
+CREATE TABLE webhook_attempts (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "webhook_event_id" UUID NOT NULL REFERENCES webhook_events (id) ON DELETE CASCADE,
+    "attempt" INTEGER NOT NULL,
+    "worker_id" TEXT NOT NULL,
+    "host" TEXT NOT NULL,
+    "started_at" TIMESTAMPTZ NOT NULL,
+    "finished_at" TIMESTAMPTZ NOT NULL,
+    "outcome" TEXT NOT NULL,
+    "error" TEXT,
+    "duration_ms" BIGINT NOT NULL,
+
+  CONSTRAINT webhook_attempts_outcome_valid
+    CHECK (outcome IN ('succeeded', 'failed')),
+
+  CONSTRAINT webhook_attempts_attempt_positive
+    CHECK (attempt > 0)
+);
+
+CREATE INDEX webhook_attempts_event_idx
+  ON webhook_attempts (webhook_event_id, attempt);
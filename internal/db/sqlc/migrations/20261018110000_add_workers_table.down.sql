@@ -0,0 +1,7 @@
+DROP INDEX webhook_events_claimed_by_idx;
+
+ALTER TABLE webhook_events
+  DROP COLUMN "claimed_at",
+  DROP COLUMN "claimed_by";
+
+DROP TABLE workers;
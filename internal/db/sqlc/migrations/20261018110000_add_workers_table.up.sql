@@ -0,0 +1,17 @@
+CREATE TABLE workers (
+    "id" UUID PRIMARY KEY,
+    "host" TEXT NOT NULL,
+    "pid" INTEGER NOT NULL,
+    "version" TEXT NOT NULL,
+    "concurrency" INTEGER NOT NULL,
+    "started_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "heartbeat_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "stopped_at" TIMESTAMPTZ
+);
+
+ALTER TABLE webhook_events
+  ADD COLUMN "claimed_by" UUID REFERENCES workers (id) ON DELETE SET NULL,
+  ADD COLUMN "claimed_at" TIMESTAMPTZ;
+
+CREATE INDEX webhook_events_claimed_by_idx
+  ON webhook_events (claimed_by) WHERE status = 'processing';
@@ -0,0 +1,7 @@
+CREATE TABLE queue_controls (
+    -- scope is an event type, or '*' for every type.
+    "scope" TEXT PRIMARY KEY,
+    "paused" BOOLEAN NOT NULL,
+    "reason" TEXT,
+    "updated_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
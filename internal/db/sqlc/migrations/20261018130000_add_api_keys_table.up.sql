@@ -0,0 +1,16 @@
+CREATE TABLE api_keys (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "name" TEXT NOT NULL,
+    -- prefix is the first characters of the key, kept in clear so a key can
+    -- be recognised in logs and listings without storing the secret.
+    "prefix" TEXT NOT NULL,
+    "key_hash" TEXT NOT NULL UNIQUE,
+    "tenant_id" TEXT NOT NULL,
+    "scopes" TEXT[] NOT NULL,
+    "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "last_used_at" TIMESTAMPTZ,
+    "revoked_at" TIMESTAMPTZ,
+
+  CONSTRAINT api_keys_scopes_valid
+    CHECK (scopes <@ ARRAY['ingest', 'read', 'admin']::TEXT[] AND cardinality(scopes) > 0)
+);
@@ -0,0 +1,8 @@
+DROP INDEX webhook_events_tenant_status_idx;
+
+ALTER TABLE webhook_events
+  DROP CONSTRAINT webhook_events_tenant_event_id_key,
+  ADD CONSTRAINT webhook_events_event_id_key UNIQUE (event_id);
+
+ALTER TABLE webhook_events
+  DROP COLUMN "tenant_id";
@@ -0,0 +1,11 @@
+-- Existing events predate tenancy and belong to the default tenant.
+ALTER TABLE webhook_events
+  ADD COLUMN "tenant_id" TEXT NOT NULL DEFAULT 'default';
+
+-- Providers pick event IDs, so two tenants may legitimately send the same one.
+ALTER TABLE webhook_events
+  DROP CONSTRAINT webhook_events_event_id_key,
+  ADD CONSTRAINT webhook_events_tenant_event_id_key UNIQUE (tenant_id, event_id);
+
+CREATE INDEX webhook_events_tenant_status_idx
+  ON webhook_events (status, tenant_id, received_at);
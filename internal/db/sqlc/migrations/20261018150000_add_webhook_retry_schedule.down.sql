@@ -0,0 +1,13 @@
+UPDATE webhook_attempts SET outcome = 'failed' WHERE outcome = 'retrying';
+
+ALTER TABLE webhook_attempts
+  DROP CONSTRAINT webhook_attempts_outcome_valid,
+  ADD CONSTRAINT webhook_attempts_outcome_valid
+    CHECK (outcome IN ('succeeded', 'failed'));
+
+DROP INDEX webhook_events_status_idx;
+
+CREATE INDEX webhook_events_status_idx
+  ON webhook_events (status, received_at);
+
+ALTER TABLE webhook_events DROP COLUMN "next_attempt_at";
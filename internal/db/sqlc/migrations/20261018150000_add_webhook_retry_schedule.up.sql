@@ -0,0 +1,13 @@
+ALTER TABLE webhook_events
+  ADD COLUMN "next_attempt_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+DROP INDEX webhook_events_status_idx;
+
+CREATE INDEX webhook_events_status_idx
+  ON webhook_events (status, next_attempt_at, received_at);
+
+-- A failed attempt that leaves the event scheduled for another is retrying.
+ALTER TABLE webhook_attempts
+  DROP CONSTRAINT webhook_attempts_outcome_valid,
+  ADD CONSTRAINT webhook_attempts_outcome_valid
+    CHECK (outcome IN ('succeeded', 'retrying', 'failed'));
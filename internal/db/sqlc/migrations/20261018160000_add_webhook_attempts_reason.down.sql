@@ -0,0 +1,2 @@
+ALTER TABLE webhook_attempts
+  DROP COLUMN "reason";
@@ -0,0 +1,5 @@
+-- Why a failed attempt failed. Attempts recorded before this column have none.
+ALTER TABLE webhook_attempts
+  ADD COLUMN "reason" TEXT,
+  ADD CONSTRAINT webhook_attempts_reason_valid
+    CHECK (reason IN ('error', 'panic', 'timeout'));
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL schema migrations so binaries do not
+// depend on the working directory they are started from.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,10 @@
+-- name: SetQueuePaused :one
+INSERT INTO queue_controls (scope, paused, reason, updated_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+ON CONFLICT (scope) DO UPDATE
+SET paused = EXCLUDED.paused, reason = EXCLUDED.reason, updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: ListQueueControls :many
+SELECT * FROM queue_controls
+ORDER BY scope ASC;
@@ -0,0 +1,10 @@
+-- name: CreateWebhookAttempt :one
+INSERT INTO webhook_attempts (
+  webhook_event_id, attempt, worker_id, host, started_at, finished_at, outcome, reason, error, duration_ms
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: ListWebhookAttempts :many
+SELECT * FROM webhook_attempts
+WHERE webhook_event_id = $1
+ORDER BY attempt ASC, started_at ASC;
@@ -1,27 +1,132 @@
 -- name: CreateWebhook :one
-INSERT INTO webhook_events (event_id, type, payload) VALUES ($1, $2, $3)
+INSERT INTO webhook_events (tenant_id, event_id, type, payload) VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: ClaimNextWebhook :one
+-- Claims the oldest claimable event. With partition_by set to 'tenant' or
+-- 'type' the claim is restricted to that partition, which is how the worker
+-- pool's fair schedulers pick whose turn it is; an empty partition_by is
+-- plain FIFO across everything.
 UPDATE webhook_events
-SET status = 'processing', attempts = attempts + 1, updated_at = CURRENT_TIMESTAMP
+SET status = 'processing', attempts = attempts + 1,
+    claimed_by = sqlc.arg(claimed_by)::uuid, claimed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = (
   SELECT id FROM webhook_events
-  WHERE status = 'received'
+  WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+    AND COALESCE(type, '') <> ALL(COALESCE(sqlc.arg(excluded_types)::text[], '{}'))
+    AND NOT EXISTS (
+      SELECT 1 FROM queue_controls qc
+      WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+    )
+    AND CASE sqlc.arg(partition_by)::text
+      WHEN 'tenant' THEN tenant_id = sqlc.arg(partition)::text
+      WHEN 'type' THEN COALESCE(type, '') = sqlc.arg(partition)::text
+      ELSE TRUE
+    END
   ORDER BY received_at ASC
   LIMIT 1
   FOR UPDATE SKIP LOCKED
 )
 RETURNING *;
 
+-- name: ListClaimablePartitions :many
+-- Partitions (tenants or types, per partition_by) that have an event
+-- ClaimNextWebhook could claim right now, with how many.
+SELECT
+  (CASE sqlc.arg(partition_by)::text WHEN 'tenant' THEN tenant_id ELSE COALESCE(type, '') END)::text AS partition,
+  COUNT(*) AS depth
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND COALESCE(type, '') <> ALL(COALESCE(sqlc.arg(excluded_types)::text[], '{}'))
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  )
+GROUP BY 1
+ORDER BY 1;
+
 -- name: MarkWebhookDone :one
+-- The settle queries are fenced on the claim like RenewWebhookLease: no row
+-- comes back once the lease has been lost and the event reclaimed.
 UPDATE webhook_events
 SET status = 'done', processed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
+WHERE id = sqlc.arg(id) AND status = 'processing' AND claimed_by = sqlc.arg(claimed_by)::uuid
 RETURNING *;
 
 -- name: MarkWebhookFailed :one
 UPDATE webhook_events
-SET status = 'failed', last_error = $2, updated_at = CURRENT_TIMESTAMP
-WHERE id = $1
+SET status = 'failed', last_error = sqlc.arg(last_error), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'processing' AND claimed_by = sqlc.arg(claimed_by)::uuid
+RETURNING *;
+
+-- name: RetryWebhook :one
+UPDATE webhook_events
+SET status = 'received', last_error = sqlc.arg(last_error), next_attempt_at = sqlc.arg(next_attempt_at), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'processing' AND claimed_by = sqlc.arg(claimed_by)::uuid
+RETURNING *;
+
+-- name: ReleaseWebhook :one
+-- Hands a claimed event back without counting the attempt.
+UPDATE webhook_events
+SET status = 'received', attempts = attempts - 1, claimed_by = NULL, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'processing' AND claimed_by = sqlc.arg(claimed_by)::uuid
+RETURNING *;
+
+-- name: RenewWebhookLease :one
+-- Extends the lease the claiming pool holds on an event it is processing.
+-- No row comes back once the event has been settled or reclaimed.
+UPDATE webhook_events
+SET claimed_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'processing' AND claimed_by = sqlc.arg(claimed_by)::uuid
+RETURNING *;
+
+-- name: ReclaimExpiredWebhooks :many
+-- Returns processing events to the queue when the lease on their claim has
+-- run out, or when the pool holding them has stopped, missed its
+-- heartbeats or been pruned.
+UPDATE webhook_events
+SET status = 'received', claimed_by = NULL, claimed_at = NULL,
+    last_error = 'claim expired before the attempt was settled',
+    next_attempt_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE status = 'processing'
+  AND (
+    claimed_by IS NULL
+    OR claimed_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(lease_seconds)::float8)
+    OR EXISTS (
+      SELECT 1 FROM workers w
+      WHERE w.id = webhook_events.claimed_by
+        AND (w.stopped_at IS NOT NULL
+          OR w.heartbeat_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(lease_seconds)::float8))
+    )
+  )
 RETURNING *;
+
+-- name: GetWebhookByEventID :one
+SELECT * FROM webhook_events
+WHERE tenant_id = $1 AND event_id = $2;
+
+-- name: GetQueueStats :one
+-- Backlog waiting for a worker: events a worker could claim right now.
+-- Paused types and retries that are not due yet are left out so a
+-- deliberate pause or a backoff does not trigger ingest backpressure.
+SELECT
+  COUNT(*) AS depth,
+  COALESCE(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - MIN(received_at)), 0)::float8 AS oldest_age_seconds
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  );
+
+-- name: GetTenantQueueStats :many
+-- Per-tenant share of the GetQueueStats backlog, for ingest quotas.
+SELECT tenant_id, COUNT(*) AS depth
+FROM webhook_events
+WHERE status = 'received' AND next_attempt_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM queue_controls qc
+    WHERE qc.paused AND (qc.scope = '*' OR qc.scope = COALESCE(webhook_events.type, ''))
+  )
+GROUP BY tenant_id
+ORDER BY tenant_id;
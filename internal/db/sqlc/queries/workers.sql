@@ -0,0 +1,25 @@
+-- name: RegisterWorker :one
+INSERT INTO workers (id, host, pid, version, concurrency)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: HeartbeatWorker :one
+UPDATE workers
+SET heartbeat_at = CURRENT_TIMESTAMP, concurrency = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: StopWorker :one
+UPDATE workers
+SET stopped_at = CURRENT_TIMESTAMP, heartbeat_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: ListWorkers :many
+SELECT * FROM workers
+ORDER BY started_at DESC;
+
+-- name: ListProcessingWebhooks :many
+SELECT * FROM webhook_events
+WHERE status = 'processing' AND claimed_by IS NOT NULL
+ORDER BY claimed_at ASC;
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+func logPoolStats(ctx context.Context, pool *pgxpool.Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s := pool.Stat()
+		log.Info().
+			Int32("acquired", s.AcquiredConns()).
+			Int32("idle", s.IdleConns()).
+			Int32("total", s.TotalConns()).
+			Int32("max", s.MaxConns()).
+			Int64("acquires", s.AcquireCount()).
+			Dur("acquire_duration", s.AcquireDuration()).
+			Int64("empty_acquires", s.EmptyAcquireCount()).
+			Dur("empty_acquire_wait", s.EmptyAcquireWaitTime()).
+			Int64("canceled_acquires", s.CanceledAcquireCount()).
+			Msg("Database pool stats")
+	}
+}
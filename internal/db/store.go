@@ -1,13 +1,20 @@
 package db
 
 import (
+	"context"
+	"errors"
 	sqlc "worker-pool/internal/db/sqlc/generated"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Store interface {
 	sqlc.Querier
+	Ping(ctx context.Context) error
+	// SchemaVersion reports the migration version recorded in the database;
+	// ok is false when no migration has been applied.
+	SchemaVersion(ctx context.Context) (version uint, dirty bool, ok bool, err error)
 }
 
 type PGXStore struct {
@@ -25,3 +32,20 @@ func NewStore(db *pgxpool.Pool) Store {
 func (s *PGXStore) GetDB() *pgxpool.Pool {
 	return s.db
 }
+
+func (s *PGXStore) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+func (s *PGXStore) SchemaVersion(ctx context.Context) (uint, bool, bool, error) {
+	var version int64
+	var dirty bool
+	err := s.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	return uint(version), dirty, true, nil
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"worker-pool/api"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) ListWorkers(ctx context.Context, request api.ListWorkersRequestObject) (api.ListWorkersResponseObject, error) {
+	statuses, err := h.workerService.ListWorkers(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list workers")
+		return api.ListWorkers500JSONResponse{
+			Code:    500,
+			Message: "Failed to list workers",
+		}, nil
+	}
+
+	resp := api.ListWorkers200JSONResponse{Workers: make([]api.Worker, 0, len(statuses))}
+	for _, status := range statuses {
+		resp.Workers = append(resp.Workers, toAPIWorker(status))
+	}
+	return resp, nil
+}
+
+func toAPIWorker(status services.WorkerStatus) api.Worker {
+	w := status.Worker
+
+	out := api.Worker{
+		Id:          w.ID,
+		Host:        w.Host,
+		Pid:         int(w.Pid),
+		Version:     w.Version,
+		Concurrency: int(w.Concurrency),
+		StartedAt:   w.StartedAt.Time,
+		HeartbeatAt: w.HeartbeatAt.Time,
+		State:       api.WorkerState(status.State),
+		Processing:  make([]api.ProcessingEvent, 0, len(status.Processing)),
+	}
+	if w.StoppedAt.Valid {
+		out.StoppedAt = &w.StoppedAt.Time
+	}
+
+	for _, event := range status.Processing {
+		pe := api.ProcessingEvent{
+			EventId:  event.EventID,
+			TenantId: event.TenantID,
+			Type:     event.Type,
+			Attempts: int(event.Attempts),
+		}
+		if event.ClaimedAt.Valid {
+			pe.ClaimedAt = &event.ClaimedAt.Time
+		}
+		out.Processing = append(out.Processing, pe)
+	}
+
+	return out
+}
+
+func (h *Handler) GetQueueControls(ctx context.Context, request api.GetQueueControlsRequestObject) (api.GetQueueControlsResponseObject, error) {
+	controls, err := h.queueService.List(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list queue controls")
+		return api.GetQueueControls500JSONResponse{
+			Code:    500,
+			Message: "Failed to list queue controls",
+		}, nil
+	}
+
+	resp := api.GetQueueControls200JSONResponse{Controls: make([]api.QueueControl, 0, len(controls))}
+	for _, c := range controls {
+		resp.Controls = append(resp.Controls, toAPIQueueControl(c))
+	}
+	return resp, nil
+}
+
+func (h *Handler) PauseQueue(ctx context.Context, request api.PauseQueueRequestObject) (api.PauseQueueResponseObject, error) {
+	if request.Body == nil {
+		return api.PauseQueue400JSONResponse{
+			Code:    400,
+			Message: "Invalid request body",
+		}, nil
+	}
+
+	control, err := h.queueService.Pause(ctx, deref(request.Body.Type), request.Body.Reason)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to pause queue")
+		return api.PauseQueue500JSONResponse{
+			Code:    500,
+			Message: "Failed to pause queue",
+		}, nil
+	}
+
+	log.Warn().Str("scope", control.Scope).Interface("reason", control.Reason).Msg("Queue processing paused")
+	return api.PauseQueue200JSONResponse(toAPIQueueControl(control)), nil
+}
+
+func (h *Handler) ResumeQueue(ctx context.Context, request api.ResumeQueueRequestObject) (api.ResumeQueueResponseObject, error) {
+	if request.Body == nil {
+		return api.ResumeQueue400JSONResponse{
+			Code:    400,
+			Message: "Invalid request body",
+		}, nil
+	}
+
+	control, err := h.queueService.Resume(ctx, deref(request.Body.Type))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to resume queue")
+		return api.ResumeQueue500JSONResponse{
+			Code:    500,
+			Message: "Failed to resume queue",
+		}, nil
+	}
+
+	log.Info().Str("scope", control.Scope).Msg("Queue processing resumed")
+	return api.ResumeQueue200JSONResponse(toAPIQueueControl(control)), nil
+}
+
+func toAPIQueueControl(c sqlc.QueueControl) api.QueueControl {
+	return api.QueueControl{
+		Scope:     c.Scope,
+		Paused:    c.Paused,
+		Reason:    c.Reason,
+		UpdatedAt: c.UpdatedAt.Time,
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"math"
+	"worker-pool/api"
+	"worker-pool/internal/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// Backpressure answers 503 with Retry-After for webhook deliveries while the
+// queue is saturated, unless the event type is on the priority allow-list,
+// and 429 while the sender's tenant is over its backlog quota. A nil or
+// disabled Backpressure lets everything through.
+func Backpressure(b *services.Backpressure) api.StrictMiddlewareFunc {
+	return func(f api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		if b == nil || !b.Enabled() || operationID != "WebhookPayment" {
+			return f
+		}
+
+		return func(c echo.Context, request interface{}) (interface{}, error) {
+			req, ok := request.(api.WebhookPaymentRequestObject)
+			if !ok || req.Body == nil {
+				return f(c, request)
+			}
+
+			tenant := services.TenantFrom(c.Request().Context())
+			if admit, reason := b.Admit(tenant, req.Body.Type); !admit {
+				log.Warn().Str("event_id", req.Body.EventId).Str("type", req.Body.Type).Str("tenant", tenant).Str("reason", reason).Msg("Rejected webhook under backpressure")
+				if reason == services.ReasonTenantQuota {
+					return api.WebhookPayment429JSONResponse{
+						Body: api.ErrorTooManyRequests{
+							Code:    429,
+							Message: "Tenant backlog quota exceeded, retry later",
+						},
+						Headers: api.WebhookPayment429ResponseHeaders{
+							RetryAfter: int(math.Ceil(b.RetryAfter().Seconds())),
+						},
+					}, nil
+				}
+				return api.WebhookPayment503JSONResponse{
+					Body: api.ErrorServiceUnavailable{
+						Code:    503,
+						Message: "Queue saturated, retry later",
+					},
+					Headers: api.WebhookPayment503ResponseHeaders{
+						RetryAfter: int(math.Ceil(b.RetryAfter().Seconds())),
+					},
+				}, nil
+			}
+			return f(c, request)
+		}
+	}
+}
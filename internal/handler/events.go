@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"worker-pool/api"
+	"worker-pool/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (h *Handler) GetEvent(ctx context.Context, request api.GetEventRequestObject) (api.GetEventResponseObject, error) {
+	details, err := h.webhookService.GetEvent(ctx, request.EventId)
+	if errors.Is(err, services.ErrEventNotFound) {
+		return api.GetEvent404JSONResponse{
+			Code:    404,
+			Message: "Event not found",
+		}, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("event_id", request.EventId).Msg("Failed to load event")
+		return api.GetEvent500JSONResponse{
+			Code:    500,
+			Message: "Failed to load event",
+		}, nil
+	}
+
+	return api.GetEvent200JSONResponse(toAPIEvent(details)), nil
+}
+
+func toAPIEvent(details services.EventDetails) api.Event {
+	event := details.Event
+
+	out := api.Event{
+		EventId:       event.EventID,
+		TenantId:      event.TenantID,
+		Type:          event.Type,
+		Status:        api.EventStatus(event.Status),
+		Paused:        details.Paused,
+		Attempts:      int(event.Attempts),
+		LastError:     event.LastError,
+		ReceivedAt:    event.ReceivedAt.Time,
+		UpdatedAt:     event.UpdatedAt.Time,
+		NextAttemptAt: event.NextAttemptAt.Time,
+		Payload:       map[string]interface{}{},
+		History:       make([]api.EventAttempt, 0, len(details.Attempts)),
+	}
+	if event.ProcessedAt.Valid {
+		out.ProcessedAt = &event.ProcessedAt.Time
+	}
+	if len(event.Payload) > 0 {
+		if err := json.Unmarshal(event.Payload, &out.Payload); err != nil {
+			log.Warn().Err(err).Str("event_id", event.EventID).Msg("Stored payload is not a JSON object")
+		}
+	}
+
+	for _, a := range details.Attempts {
+		out.History = append(out.History, api.EventAttempt{
+			Attempt:    int(a.Attempt),
+			WorkerId:   a.WorkerID,
+			Host:       a.Host,
+			StartedAt:  a.StartedAt.Time,
+			FinishedAt: a.FinishedAt.Time,
+			Outcome:    api.EventAttemptOutcome(a.Outcome),
+			Reason:     (*api.EventAttemptReason)(a.Reason),
+			Error:      a.Error,
+			DurationMs: a.DurationMs,
+		})
+	}
+
+	return out
+}
@@ -1,48 +1,54 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"worker-pool/api"
 	"worker-pool/internal/config"
 	"worker-pool/internal/services"
-
-	"github.com/labstack/echo/v4"
 )
 
+var _ api.StrictServerInterface = (*Handler)(nil)
+
 type Handler struct {
 	config         config.Config
 	webhookService *services.WebhookService
+	workerService  *services.WorkerService
+	queueService   *services.QueueService
 }
 
-func NewHandler(cfg config.Config, webhookService *services.WebhookService) *Handler {
+func NewHandler(cfg config.Config, webhookService *services.WebhookService, workerService *services.WorkerService, queueService *services.QueueService) *Handler {
 	return &Handler{
 		config:         cfg,
 		webhookService: webhookService,
+		workerService:  workerService,
+		queueService:   queueService,
 	}
 }
 
-func (h *Handler) WebhookPayment(ctx echo.Context, params api.WebhookPaymentParams) error {
-	var req api.WebhookPaymentJSONRequestBody
-
-	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(400, api.ErrorBadRequest{
+func (h *Handler) WebhookPayment(ctx context.Context, request api.WebhookPaymentRequestObject) (api.WebhookPaymentResponseObject, error) {
+	if request.Body == nil {
+		return api.WebhookPayment400JSONResponse{
 			Code:    400,
 			Message: "Invalid request body",
-		})
-	}
-
-	if req.Amount == "" || req.Currency == "" || req.EventId == "" || req.Type == "" {
-		return ctx.JSON(400, api.ErrorBadRequest{
-			Code:    400,
-			Message: "Missing required fields",
-		})
+		}, nil
 	}
 
-	if err := h.webhookService.ProcessPaymentWebhook(req); err != nil {
-		return ctx.JSON(500, api.ErrorInternal{
+	if err := h.webhookService.ProcessPaymentWebhook(ctx, *request.Body); err != nil {
+		var verr *services.ValidationError
+		if errors.As(err, &verr) {
+			return api.WebhookPayment400JSONResponse{
+				Code:    400,
+				Message: "Invalid webhook payload",
+				Errors:  &verr.Errors,
+			}, nil
+		}
+
+		return api.WebhookPayment500JSONResponse{
 			Code:    500,
 			Message: "Failed to process webhook",
-		})
+		}, nil
 	}
 
-	return ctx.JSON(200, api.WebhookAckResponse{Ok: true})
+	return api.WebhookPayment200JSONResponse{Ok: true}, nil
 }
@@ -2,8 +2,12 @@ package handler_test
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -11,57 +15,67 @@ import (
 	"time"
 	"worker-pool/api"
 	"worker-pool/internal/config"
-	"worker-pool/internal/handler"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	"worker-pool/internal/db/mocks"
 	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/handler"
 	"worker-pool/internal/services"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-type mockStore struct {
-	createWebhookFn func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error)
-}
-
-func (m *mockStore) ClaimNextWebhook(ctx context.Context) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
-}
-
-func (m *mockStore) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
-	if m.createWebhookFn != nil {
-		return m.createWebhookFn(ctx, arg)
+func newTestServer(store db.Store, middlewares ...api.StrictMiddlewareFunc) *echo.Echo {
+	validator, err := services.NewPaymentValidator(config.DefaultValidationConfig())
+	if err != nil {
+		panic(err)
 	}
-	return sqlc.WebhookEvent{}, nil
-}
+	svc := services.NewWebhookService(store, validator)
+	cfg := config.Default()
+	h := handler.NewHandler(cfg, svc,
+		services.NewWorkerService(store, cfg.Worker.HeartbeatTimeout),
+		services.NewQueueService(store))
 
-func (m *mockStore) MarkWebhookDone(ctx context.Context, id uuid.UUID) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.IPExtractor, err = handler.IPExtractor(cfg.Server.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	e.Use(handler.Authenticate(services.NewAPIKeyService(store)))
+	e.Use(handler.CaptureRawBody(cfg.Server.MaxBodyBytes))
+	api.RegisterHandlers(e, api.NewStrictHandler(h, middlewares))
+	return e
 }
 
-func (m *mockStore) MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
+func postWebhook(e *echo.Echo, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
 }
 
-func newTestHandler(store *mockStore) *handler.Handler {
-	svc := services.NewWebhookService(store)
-	return handler.NewHandler(config.Config{Port: "3333"}, svc)
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 func TestWebhookPayment_Success(t *testing.T) {
-	e := echo.New()
-	h := newTestHandler(&mockStore{})
+	e := newTestServer(dbtest.NewStore())
 	reqBody := `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
 
-	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(reqBody))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
-
-	err := h.WebhookPayment(c, api.WebhookPaymentParams{})
+	rec := postWebhook(e, reqBody, nil)
 
-	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
 
 	var resp api.WebhookAckResponse
@@ -70,16 +84,10 @@ func TestWebhookPayment_Success(t *testing.T) {
 }
 
 func TestWebhookPayment_InvalidJSON(t *testing.T) {
-	e := echo.New()
-	h := newTestHandler(&mockStore{})
-	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader("{"))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
+	e := newTestServer(dbtest.NewStore())
 
-	err := h.WebhookPayment(c, api.WebhookPaymentParams{})
+	rec := postWebhook(e, "{", nil)
 
-	require.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
 	var resp api.ErrorBadRequest
@@ -89,32 +97,43 @@ func TestWebhookPayment_InvalidJSON(t *testing.T) {
 }
 
 func TestWebhookPayment_MissingRequiredFields(t *testing.T) {
-	e := echo.New()
-	h := newTestHandler(&mockStore{})
+	e := newTestServer(dbtest.NewStore())
 	reqBody := `{"event_id":"evt_1","type":"payment.completed","amount":"","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
-	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(reqBody))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
 
-	err := h.WebhookPayment(c, api.WebhookPaymentParams{})
+	rec := postWebhook(e, reqBody, nil)
 
-	require.NoError(t, err)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 
 	var resp api.ErrorBadRequest
 	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
 	assert.Equal(t, 400, resp.Code)
-	assert.Equal(t, "Missing required fields", resp.Message)
+	assert.Equal(t, "Invalid webhook payload", resp.Message)
+	require.NotNil(t, resp.Errors)
+	assert.Equal(t, []api.FieldError{{Field: "amount", Message: "is required"}}, *resp.Errors)
+}
+
+func TestWebhookPayment_InvalidFields(t *testing.T) {
+	e := newTestServer(dbtest.NewStore())
+	reqBody := `{"event_id":"evt_1","type":"payment.unknown","amount":"12.x","currency":"XYZ","occurred_at":"2026-01-10T12:00:00Z"}`
+
+	rec := postWebhook(e, reqBody, nil)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp api.ErrorBadRequest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Errors)
+	fields := make([]string, 0, len(*resp.Errors))
+	for _, fe := range *resp.Errors {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"type", "amount", "currency"}, fields)
 }
 
 func TestWebhookPayment_ServiceFailure(t *testing.T) {
-	e := echo.New()
-	h := newTestHandler(&mockStore{
-		createWebhookFn: func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
-			return sqlc.WebhookEvent{}, errors.New("db down")
-		},
-	})
+	store := mocks.NewStore(t)
+	store.EXPECT().CreateWebhook(mock.Anything, mock.Anything).Return(sqlc.WebhookEvent{}, errors.New("db down"))
+	e := newTestServer(store)
 	reqModel := api.WebhookPaymentJSONRequestBody{
 		EventId:    "evt_2",
 		Type:       "payment.failed",
@@ -125,14 +144,8 @@ func TestWebhookPayment_ServiceFailure(t *testing.T) {
 	bodyBytes, err := json.Marshal(reqModel)
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(string(bodyBytes)))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
-	c := e.NewContext(req, rec)
+	rec := postWebhook(e, string(bodyBytes), nil)
 
-	err = h.WebhookPayment(c, api.WebhookPaymentParams{})
-
-	require.NoError(t, err)
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 
 	var resp api.ErrorInternal
@@ -140,3 +153,433 @@ func TestWebhookPayment_ServiceFailure(t *testing.T) {
 	assert.Equal(t, 500, resp.Code)
 	assert.Equal(t, "Failed to process webhook", resp.Message)
 }
+
+func TestWebhookPayment_RequestTimeoutReachesStore(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	store := mocks.NewStore(t)
+	store.EXPECT().CreateWebhook(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
+			deadline, hasDeadline = ctx.Deadline()
+			return sqlc.WebhookEvent{}, nil
+		})
+	e := newTestServer(store, handler.RequestTimeout(2*time.Second))
+	reqBody := `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+
+	rec := postWebhook(e, reqBody, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+}
+
+func TestWebhookPayment_SignatureAuth(t *testing.T) {
+	const secret = "s3cret"
+	reqBody := `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+
+	tests := []struct {
+		name         string
+		signature    string
+		expectedCode int
+	}{
+		{name: "success - valid signature", signature: sign(secret, reqBody), expectedCode: http.StatusOK},
+		{name: "error - missing signature", signature: "", expectedCode: http.StatusUnauthorized},
+		{name: "error - wrong secret", signature: sign("other", reqBody), expectedCode: http.StatusUnauthorized},
+		{name: "error - not hex", signature: "not-a-signature", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestServer(dbtest.NewStore(), handler.SignatureAuth(secret))
+			headers := map[string]string{}
+			if tt.signature != "" {
+				headers["X-Webhook-Signature"] = tt.signature
+			}
+
+			rec := postWebhook(e, reqBody, headers)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func getEvent(e *echo.Echo, eventID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/events/"+eventID, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetEvent_WithHistory(t *testing.T) {
+	received := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	eventType := "payment.completed"
+	firstErr := "handler timed out after 30s"
+	timeoutReason := "timeout"
+	store := dbtest.NewStore()
+	event := store.PutEvent(sqlc.WebhookEvent{
+		TenantID:      services.DefaultTenant,
+		EventID:       "evt_1",
+		Type:          &eventType,
+		Payload:       []byte(`{"amount":"5000"}`),
+		Status:        "done",
+		Attempts:      2,
+		ReceivedAt:    pgtype.Timestamptz{Time: received, Valid: true},
+		UpdatedAt:     pgtype.Timestamptz{Time: received, Valid: true},
+		NextAttemptAt: pgtype.Timestamptz{Time: received, Valid: true},
+		ProcessedAt:   pgtype.Timestamp{Time: received.Add(time.Minute), Valid: true},
+	})
+	for _, attempt := range []sqlc.CreateWebhookAttemptParams{
+		{Attempt: 2, WorkerID: "host-1-2", Host: "host", Outcome: "succeeded", DurationMs: 95},
+		{Attempt: 1, WorkerID: "host-1-1", Host: "host", Outcome: "retrying", Reason: &timeoutReason, Error: &firstErr, DurationMs: 120},
+	} {
+		attempt.WebhookEventID = event.ID
+		_, err := store.CreateWebhookAttempt(context.Background(), attempt)
+		require.NoError(t, err)
+	}
+	e := newTestServer(store)
+
+	rec := getEvent(e, "evt_1")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp api.Event
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "evt_1", resp.EventId)
+	assert.Equal(t, api.EventStatusDone, resp.Status)
+	assert.Equal(t, "5000", resp.Payload["amount"])
+	require.NotNil(t, resp.ProcessedAt)
+	require.Len(t, resp.History, 2)
+	assert.Equal(t, api.EventAttemptOutcomeRetrying, resp.History[0].Outcome)
+	assert.Equal(t, &firstErr, resp.History[0].Error)
+	require.NotNil(t, resp.History[0].Reason)
+	assert.Equal(t, api.Timeout, *resp.History[0].Reason)
+	assert.Equal(t, api.EventAttemptOutcomeSucceeded, resp.History[1].Outcome)
+	assert.Nil(t, resp.History[1].Error)
+	assert.Nil(t, resp.History[1].Reason)
+}
+
+func TestGetEvent_NotFound(t *testing.T) {
+	e := newTestServer(dbtest.NewStore())
+
+	rec := getEvent(e, "evt_missing")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetEvent_StoreError(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().GetWebhookByEventID(mock.Anything, mock.Anything).Return(sqlc.WebhookEvent{}, errors.New("db down"))
+	e := newTestServer(store)
+
+	rec := getEvent(e, "evt_1")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestListWorkers(t *testing.T) {
+	id := uuid.New()
+	eventType := "payment.completed"
+	store := dbtest.NewStore()
+	store.PutWorker(sqlc.Worker{
+		ID:          id,
+		Host:        "worker-host",
+		Pid:         4242,
+		Version:     "dev",
+		Concurrency: 5,
+		StartedAt:   pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+		HeartbeatAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	store.PutEvent(sqlc.WebhookEvent{
+		EventID:   "evt_1",
+		Type:      &eventType,
+		Status:    db.ProcessingStatus,
+		Attempts:  1,
+		ClaimedBy: pgtype.UUID{Bytes: id, Valid: true},
+		ClaimedAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+	})
+	e := newTestServer(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/workers", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp api.WorkerList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Workers, 1)
+	assert.Equal(t, id, resp.Workers[0].Id)
+	assert.Equal(t, api.Live, resp.Workers[0].State)
+	require.Len(t, resp.Workers[0].Processing, 1)
+	assert.Equal(t, "evt_1", resp.Workers[0].Processing[0].EventId)
+	assert.NotNil(t, resp.Workers[0].Processing[0].ClaimedAt)
+}
+
+func adminPost(e *echo.Echo, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPauseAndResumeQueue(t *testing.T) {
+	eventType := "payment.refunded"
+	store := dbtest.NewStore()
+	store.PutEvent(sqlc.WebhookEvent{TenantID: services.DefaultTenant, EventID: "evt_1", Type: &eventType})
+	e := newTestServer(store)
+
+	rec := adminPost(e, "/admin/queue/pause", `{"type":"payment.refunded","reason":"incident"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var control api.QueueControl
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &control))
+	assert.Equal(t, "payment.refunded", control.Scope)
+	assert.True(t, control.Paused)
+
+	var event api.Event
+	rec = getEvent(e, "evt_1")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &event))
+	assert.True(t, event.Paused)
+
+	rec = adminPost(e, "/admin/queue/resume", `{"type":"payment.refunded"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = getEvent(e, "evt_1")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &event))
+	assert.False(t, event.Paused)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/queue", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list api.QueueControlList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Controls, 1)
+	assert.False(t, list.Controls[0].Paused)
+}
+
+func TestPauseQueue_Global(t *testing.T) {
+	e := newTestServer(dbtest.NewStore())
+
+	rec := adminPost(e, "/admin/queue/pause", `{}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var control api.QueueControl
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &control))
+	assert.Equal(t, "*", control.Scope)
+}
+
+func TestWebhookPayment_BodyTooLarge(t *testing.T) {
+	e := newTestServer(dbtest.NewStore())
+	body := `{"event_id":"evt_1","padding":"` + strings.Repeat("x", 2<<20) + `"}`
+
+	rec := postWebhook(e, body, nil)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	var resp api.ErrorBadRequest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 413, resp.Code)
+}
+
+func TestWebhookPayment_RateLimit(t *testing.T) {
+	// Replaced per case with a key issued by the test store, since unknown
+	// keys are rejected before the limiter runs.
+	const apiKeyPlaceholder = "<api key>"
+	first := `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+	second := `{"event_id":"evt_2","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+
+	tests := []struct {
+		name     string
+		keyBy    string
+		first    map[string]string
+		second   map[string]string
+		expected int
+	}{
+		{
+			name:     "same ip shares a bucket",
+			keyBy:    config.RateLimitByIP,
+			first:    map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			second:   map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expected: http.StatusTooManyRequests,
+		},
+		{
+			name:     "different ips have their own buckets",
+			keyBy:    config.RateLimitByIP,
+			first:    map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			second:   map[string]string{"X-Forwarded-For": "10.0.0.2"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "providers are keyed separately from ip",
+			keyBy:    config.RateLimitByProvider,
+			first:    map[string]string{"X-Webhook-Provider": "paystack"},
+			second:   map[string]string{"X-Webhook-Provider": "flutterwave"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "same api key shares a bucket across ips",
+			keyBy:    config.RateLimitByAPIKey,
+			first:    map[string]string{"X-API-Key": apiKeyPlaceholder, "X-Forwarded-For": "10.0.0.1"},
+			second:   map[string]string{"X-API-Key": apiKeyPlaceholder, "X-Forwarded-For": "10.0.0.2"},
+			expected: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := dbtest.NewStore()
+			apiKey, _, err := services.NewAPIKeyService(store).Create(context.Background(), "provider", "acme", []string{services.ScopeIngest})
+			require.NoError(t, err)
+			for _, headers := range []map[string]string{tt.first, tt.second} {
+				if headers["X-API-Key"] == apiKeyPlaceholder {
+					headers["X-API-Key"] = apiKey
+				}
+			}
+
+			limiter := handler.NewRateLimiter(config.RateLimitConfig{RPS: 0.5, Burst: 1, KeyBy: tt.keyBy})
+			e := newTestServer(store, handler.RateLimit(limiter))
+			// httptest requests come from 192.0.2.1, standing in for a proxy.
+			e.IPExtractor, err = handler.IPExtractor([]string{"192.0.2.0/24"})
+			require.NoError(t, err)
+
+			require.Equal(t, http.StatusOK, postWebhook(e, first, tt.first).Code)
+			rec := postWebhook(e, second, tt.second)
+
+			assert.Equal(t, tt.expected, rec.Code)
+			if tt.expected == http.StatusTooManyRequests {
+				assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+				var resp api.ErrorTooManyRequests
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, 429, resp.Code)
+			}
+		})
+	}
+}
+
+func TestRateLimit_IgnoresSpoofedForwardingHeaders(t *testing.T) {
+	first := `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+	second := `{"event_id":"evt_2","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`
+	limiter := handler.NewRateLimiter(config.RateLimitConfig{RPS: 0.5, Burst: 1, KeyBy: config.RateLimitByIP})
+	e := newTestServer(dbtest.NewStore(), handler.RateLimit(limiter))
+
+	require.Equal(t, http.StatusOK, postWebhook(e, first, map[string]string{"X-Forwarded-For": "10.0.0.1"}).Code)
+	rec := postWebhook(e, second, map[string]string{"X-Forwarded-For": "10.0.0.2", "X-Real-IP": "10.0.0.3"})
+
+	// Without trusted proxies both requests come from their connection's
+	// address, whatever they claim.
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestIPExtractor_RejectsInvalidCIDR(t *testing.T) {
+	_, err := handler.IPExtractor([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestRateLimit_DisabledWithZeroRPS(t *testing.T) {
+	limiter := handler.NewRateLimiter(config.RateLimitConfig{RPS: 0, Burst: 1, KeyBy: config.RateLimitByIP})
+	e := newTestServer(dbtest.NewStore(), handler.RateLimit(limiter))
+	for i := range 5 {
+		body := fmt.Sprintf(`{"event_id":"evt_%d","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`, i)
+		assert.Equal(t, http.StatusOK, postWebhook(e, body, nil).Code)
+	}
+}
+
+func TestWebhookPayment_Backpressure(t *testing.T) {
+	store := dbtest.NewStore()
+	for i := range 1000 {
+		store.PutEvent(sqlc.WebhookEvent{TenantID: "backlog", EventID: fmt.Sprintf("evt_backlog_%d", i)})
+	}
+	bp := services.NewBackpressure(store, config.BackpressureConfig{
+		MaxQueueDepth: 10,
+		CheckInterval: time.Second,
+		RetryAfter:    45 * time.Second,
+		PriorityTypes: []string{"payment.refunded"},
+	})
+	require.NoError(t, bp.Refresh(context.Background()))
+	e := newTestServer(store, handler.Backpressure(bp))
+
+	rec := postWebhook(e, `{"event_id":"evt_1","type":"payment.completed","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`, nil)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "45", rec.Header().Get("Retry-After"))
+	var resp api.ErrorServiceUnavailable
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 503, resp.Code)
+
+	rec = postWebhook(e, `{"event_id":"evt_2","type":"payment.refunded","amount":"5000","currency":"NGN","occurred_at":"2026-01-10T12:00:00Z"}`, nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	store := dbtest.NewStore()
+	store.PutEvent(sqlc.WebhookEvent{TenantID: "acme", EventID: "evt_1"})
+	keys := services.NewAPIKeyService(store)
+	ctx := context.Background()
+	ingestKey, _, err := keys.Create(ctx, "provider", "acme", []string{services.ScopeIngest})
+	require.NoError(t, err)
+	readKey, _, err := keys.Create(ctx, "dashboard", "acme", []string{services.ScopeRead})
+	require.NoError(t, err)
+	revokedKey, revoked, err := keys.Create(ctx, "old", "acme", []string{services.ScopeRead})
+	require.NoError(t, err)
+	_, err = keys.Revoke(ctx, revoked.ID)
+	require.NoError(t, err)
+	operatorKey, _, err := keys.Create(ctx, "on-call", services.OperatorTenant, []string{services.ScopeAdmin})
+	require.NoError(t, err)
+	// Tenant-bound admin keys can no longer be created, but may predate
+	// operator keys.
+	tenantAdminKey := "wpk_tenant-admin"
+	sum := sha256.Sum256([]byte(tenantAdminKey))
+	_, err = store.CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		Name:     "legacy admin",
+		Prefix:   tenantAdminKey[:8],
+		KeyHash:  hex.EncodeToString(sum[:]),
+		TenantID: "acme",
+		Scopes:   []string{services.ScopeAdmin},
+	})
+	require.NoError(t, err)
+
+	e := newTestServer(store, handler.RequireScopes(true))
+	request := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		apiKey   string
+		expected int
+	}{
+		{"missing key", http.MethodGet, "/events/evt_1", "", http.StatusUnauthorized},
+		{"revoked key", http.MethodGet, "/events/evt_1", revokedKey, http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/events/evt_1", "wpk_not-a-real-key", http.StatusUnauthorized},
+		{"read scope", http.MethodGet, "/events/evt_1", readKey, http.StatusOK},
+		{"ingest key reading", http.MethodGet, "/events/evt_1", ingestKey, http.StatusForbidden},
+		{"read key on admin", http.MethodGet, "/admin/workers", readKey, http.StatusForbidden},
+		{"operator key on admin", http.MethodGet, "/admin/workers", operatorKey, http.StatusOK},
+		{"tenant admin key on admin", http.MethodPost, "/admin/queue/pause", tenantAdminKey, http.StatusForbidden},
+		{"operator key reading", http.MethodGet, "/events/evt_1", operatorKey, http.StatusForbidden},
+		{"read key ingesting", http.MethodPost, "/webhooks/payments", readKey, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(tt.method, tt.path, tt.apiKey)
+			assert.Equal(t, tt.expected, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestAPIKeyAuth_Optional(t *testing.T) {
+	store := dbtest.NewStore()
+	store.PutEvent(sqlc.WebhookEvent{TenantID: services.DefaultTenant, EventID: "evt_1"})
+	e := newTestServer(store, handler.RequireScopes(false))
+
+	rec := getEvent(e, "evt_1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"worker-pool/api"
+	"worker-pool/internal/services"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const rawBodyKey = "raw_body"
+
+// CaptureRawBody keeps a copy of the request body on the echo context. The
+// strict handler decodes the body before any strict middleware runs, so
+// SignatureAuth needs this to see the bytes the sender signed. Bodies over
+// maxBytes are rejected with 413 without being read in full.
+func CaptureRawBody(maxBytes int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().ContentLength > maxBytes {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body too large")
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body too large")
+				}
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body").SetInternal(err)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+			c.Set(rawBodyKey, body)
+			return next(c)
+		}
+	}
+}
+
+// SignatureAuth rejects webhook deliveries whose X-Webhook-Signature is not
+// the hex HMAC-SHA256 of the raw body under secret. An empty secret disables
+// the check.
+func SignatureAuth(secret string) api.StrictMiddlewareFunc {
+	return func(f api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		if secret == "" || operationID != "WebhookPayment" {
+			return f
+		}
+
+		return func(c echo.Context, request interface{}) (interface{}, error) {
+			body, _ := c.Get(rawBodyKey).([]byte)
+			if !validSignature(secret, body, c.Request().Header.Get("X-Webhook-Signature")) {
+				log.Warn().Str("operation", operationID).Str("remote_ip", c.RealIP()).Msg("Rejected webhook with invalid signature")
+				return api.WebhookPayment401JSONResponse{
+					Code:    401,
+					Message: "Invalid webhook signature",
+				}, nil
+			}
+			return f(c, request)
+		}
+	}
+}
+
+func validSignature(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// RequestTimeout bounds the context handed to the strict handler, and from
+// there to the service and store.
+func RequestTimeout(d time.Duration) api.StrictMiddlewareFunc {
+	return func(f api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		if d <= 0 {
+			return f
+		}
+
+		return func(c echo.Context, request interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return f(c, request)
+		}
+	}
+}
+
+func RequestLogging() api.StrictMiddlewareFunc {
+	return func(f api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		return func(c echo.Context, request interface{}) (interface{}, error) {
+			start := time.Now()
+			response, err := f(c, request)
+
+			event := log.Info()
+			if err != nil {
+				event = log.Error().Err(err)
+			}
+			if principal, ok := services.PrincipalFrom(c.Request().Context()); ok {
+				event = event.Str("api_key", principal.Prefix).Str("tenant", principal.TenantID)
+			}
+			event.
+				Str("operation", operationID).
+				Str("response", fmt.Sprintf("%T", response)).
+				Dur("duration", time.Since(start)).
+				Msg("Handled request")
+
+			return response, err
+		}
+	}
+}
+
+// HTTPErrorHandler renders errors raised outside the strict handlers, such
+// as body binding failures, using the API error schemas.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	code := http.StatusInternalServerError
+	message := "Internal server error"
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		code = he.Code
+		message = fmt.Sprint(he.Message)
+		if code == http.StatusBadRequest && he.Internal != nil {
+			message = "Invalid request body"
+		}
+	}
+
+	var body interface{} = api.ErrorBadRequest{Code: code, Message: message}
+	if code >= http.StatusInternalServerError {
+		log.Error().Err(err).Str("path", c.Path()).Msg("Unhandled request error")
+		body = api.ErrorInternal{Code: code, Message: "Internal server error"}
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(code)
+	} else {
+		err = c.JSON(code, body)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to write error response")
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+	"worker-pool/api"
+	"worker-pool/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long an idle sender's bucket is kept. By then it has
+// refilled, so dropping it changes nothing but memory use.
+const limiterIdleTTL = 10 * time.Minute
+
+// RateLimiter holds one token bucket per sender.
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	keyBy string
+	now   func() time.Time
+
+	mu        sync.Mutex
+	senders   map[string]*sender
+	lastSweep time.Time
+}
+
+type sender struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		limit:   rate.Limit(cfg.RPS),
+		burst:   cfg.Burst,
+		keyBy:   cfg.KeyBy,
+		now:     time.Now,
+		senders: make(map[string]*sender),
+	}
+}
+
+// RateLimit answers 429 with Retry-After once a sender has used its bucket
+// on the ingest endpoint. A nil limiter, or one with zero RPS, disables it.
+func RateLimit(l *RateLimiter) api.StrictMiddlewareFunc {
+	return func(f api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		if l == nil || l.limit <= 0 || operationID != "WebhookPayment" {
+			return f
+		}
+
+		return func(c echo.Context, request interface{}) (interface{}, error) {
+			key := l.key(c)
+			if wait := l.reserve(key); wait > 0 {
+				log.Warn().Str("operation", operationID).Str("sender", key).Dur("retry_after", wait).Msg("Rate limited request")
+				return api.WebhookPayment429JSONResponse{
+					Body: api.ErrorTooManyRequests{
+						Code:    429,
+						Message: "Rate limit exceeded",
+					},
+					Headers: api.WebhookPayment429ResponseHeaders{
+						RetryAfter: int(math.Ceil(wait.Seconds())),
+					},
+				}, nil
+			}
+			return f(c, request)
+		}
+	}
+}
+
+// key identifies the sender. Header-based keys fall back to the client IP
+// so requests without the header still share a bucket per address. API keys
+// are hashed so the limiter never holds the secret itself.
+func (l *RateLimiter) key(c echo.Context) string {
+	switch l.keyBy {
+	case config.RateLimitByProvider:
+		if provider := c.Request().Header.Get("X-Webhook-Provider"); provider != "" {
+			return "provider:" + provider
+		}
+	case config.RateLimitByAPIKey:
+		if apiKey := c.Request().Header.Get("X-API-Key"); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return "ip:" + c.RealIP()
+}
+
+// reserve takes a token for key and returns zero, or how long the sender
+// must wait for one when its bucket is empty.
+func (l *RateLimiter) reserve(key string) time.Duration {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	s, ok := l.senders[key]
+	if !ok {
+		s = &sender{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.senders[key] = s
+	}
+	s.lastSeen = now
+
+	r := s.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay
+	}
+	return 0
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, s := range l.senders {
+		if now.Sub(s.lastSeen) > limiterIdleTTL {
+			delete(l.senders, key)
+		}
+	}
+}
+
+// IPExtractor decides the client IP that rate limiting and request logs
+// see. Forwarding headers are only believed from the trusted proxy ranges:
+// with none, the connection's address is used, so a client cannot pick
+// its own rate limit bucket by sending X-Forwarded-For or X-Real-IP.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", cidr, err)
+		}
+		opts = append(opts, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
@@ -0,0 +1,127 @@
+// Package health serves liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const checkTimeout = 2 * time.Second
+
+// Check returns nil when the dependency it probes is usable.
+type Check func(ctx context.Context) error
+
+// Checker runs the readiness checks registered with Add. Liveness only
+// reports that the process can serve HTTP.
+type Checker struct {
+	mu       sync.Mutex
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// Report is the JSON body of both probes.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// SetDraining makes readiness fail from now on, so load balancers stop
+// routing to a process that is shutting down.
+func (c *Checker) SetDraining() {
+	if !c.draining.Swap(true) {
+		log.Info().Msg("Draining; readiness now reports unavailable")
+	}
+}
+
+// Ready runs every check concurrently and reports whether all passed.
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	c.mu.Lock()
+	names := append([]string(nil), c.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	results := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: make(map[string]string, len(names)+1)}
+	ready := true
+	for i, name := range names {
+		report.Checks[name] = "ok"
+		if results[i] != nil {
+			report.Checks[name] = results[i].Error()
+			ready = false
+		}
+	}
+	if c.draining.Load() {
+		report.Checks["drain"] = "draining"
+		ready = false
+	}
+	if !ready {
+		report.Status = "unavailable"
+	}
+	return report, ready
+}
+
+// LivenessHandler serves /healthz.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: "ok"})
+	})
+}
+
+// ReadinessHandler serves /readyz: 200 when every check passes and the
+// process is not draining, 503 otherwise.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ready := c.Ready(r.Context())
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(w, code, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, code int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Error().Err(err).Msg("Failed to write health report")
+	}
+}
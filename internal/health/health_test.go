@@ -0,0 +1,100 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"worker-pool/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler) (int, health.Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func ok(context.Context) error { return nil }
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name         string
+		checks       map[string]health.Check
+		drain        bool
+		expectedCode int
+		expected     map[string]string
+	}{
+		{
+			name:         "all checks pass",
+			checks:       map[string]health.Check{"database": ok, "migrations": ok},
+			expectedCode: http.StatusOK,
+			expected:     map[string]string{"database": "ok", "migrations": "ok"},
+		},
+		{
+			name: "failing check",
+			checks: map[string]health.Check{
+				"database":   func(context.Context) error { return errors.New("connection refused") },
+				"migrations": ok,
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     map[string]string{"database": "connection refused", "migrations": "ok"},
+		},
+		{
+			name:         "draining",
+			checks:       map[string]health.Check{"database": ok},
+			drain:        true,
+			expectedCode: http.StatusServiceUnavailable,
+			expected:     map[string]string{"database": "ok", "drain": "draining"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker()
+			for name, check := range tt.checks {
+				checker.Add(name, check)
+			}
+			if tt.drain {
+				checker.SetDraining()
+			}
+
+			code, report := probe(t, checker.ReadinessHandler())
+
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expected, report.Checks)
+		})
+	}
+}
+
+func TestReadiness_CheckTimeout(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, report := probe(t, checker.ReadinessHandler())
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["database"])
+}
+
+func TestLiveness_IgnoresChecksAndDrain(t *testing.T) {
+	checker := health.NewChecker()
+	checker.Add("database", func(context.Context) error { return errors.New("down") })
+	checker.SetDraining()
+
+	code, report := probe(t, checker.LivenessHandler())
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", report.Status)
+}
@@ -0,0 +1,297 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createEvent(t *testing.T, tenant, eventID, eventType string) sqlc.WebhookEvent {
+	t.Helper()
+	event, err := store.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{
+		TenantID: tenant,
+		EventID:  eventID,
+		Type:     &eventType,
+		Payload:  []byte(`{}`),
+	})
+	require.NoError(t, err)
+	return event
+}
+
+// registerWorker adds a worker to claim with; claimed_by references workers.
+func registerWorker(t *testing.T) uuid.UUID {
+	t.Helper()
+	worker, err := store.RegisterWorker(context.Background(), sqlc.RegisterWorkerParams{
+		ID:          uuid.New(),
+		Host:        "integration",
+		Pid:         int32(os.Getpid()),
+		Version:     "test",
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+	return worker.ID
+}
+
+func TestClaimNextWebhook_ConcurrentClaimersNeverShareAnEvent(t *testing.T) {
+	resetDB(t)
+	ctx := context.Background()
+
+	const events, claimers = 500, 32
+	for i := range events {
+		createEvent(t, "default", fmt.Sprintf("evt_%d", i), "payment.completed")
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[uuid.UUID][]uuid.UUID)
+		errs    []error
+		wg      sync.WaitGroup
+	)
+	start := make(chan struct{})
+	for range claimers {
+		workerID := registerWorker(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for {
+				event, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+				if errors.Is(err, pgx.ErrNoRows) {
+					return
+				}
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+					mu.Unlock()
+					return
+				}
+				claimed[event.ID] = append(claimed[event.ID], workerID)
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	require.Empty(t, errs)
+	assert.Len(t, claimed, events)
+	for id, workers := range claimed {
+		assert.Len(t, workers, 1, "event %s claimed by %v", id, workers)
+	}
+
+	var processing, attempts int
+	err := pool.QueryRow(ctx,
+		"SELECT COUNT(*), COALESCE(SUM(attempts), 0) FROM webhook_events WHERE status = $1 AND claimed_by IS NOT NULL",
+		db.ProcessingStatus).Scan(&processing, &attempts)
+	require.NoError(t, err)
+	assert.Equal(t, events, processing)
+	assert.Equal(t, events, attempts)
+}
+
+func TestClaimNextWebhook_ConcurrentPartitionedClaimers(t *testing.T) {
+	resetDB(t)
+	ctx := context.Background()
+
+	tenants := []string{"acme", "globex", "initech"}
+	const perTenant, claimersPerTenant = 100, 8
+	for _, tenant := range tenants {
+		for i := range perTenant {
+			createEvent(t, tenant, fmt.Sprintf("evt_%d", i), "payment.completed")
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = make(map[uuid.UUID]string)
+		dupes   int
+		wg      sync.WaitGroup
+	)
+	for _, tenant := range tenants {
+		for range claimersPerTenant {
+			params := sqlc.ClaimNextWebhookParams{ClaimedBy: registerWorker(t), PartitionBy: "tenant", Partition: tenant}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					event, err := store.ClaimNextWebhook(ctx, params)
+					if err != nil {
+						assert.ErrorIs(t, err, pgx.ErrNoRows)
+						return
+					}
+					assert.Equal(t, tenant, event.TenantID)
+					mu.Lock()
+					if _, ok := claimed[event.ID]; ok {
+						dupes++
+					}
+					claimed[event.ID] = event.TenantID
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Zero(t, dupes)
+	assert.Len(t, claimed, len(tenants)*perTenant)
+}
+
+func TestClaimNextWebhook_OldestFirst(t *testing.T) {
+	resetDB(t)
+	ctx := context.Background()
+
+	newest := createEvent(t, "default", "evt_newest", "payment.completed")
+	oldest := createEvent(t, "default", "evt_oldest", "payment.completed")
+	middle := createEvent(t, "default", "evt_middle", "payment.completed")
+	now := time.Now()
+	for id, age := range map[uuid.UUID]time.Duration{newest.ID: time.Minute, oldest.ID: time.Hour, middle.ID: 10 * time.Minute} {
+		_, err := pool.Exec(ctx, "UPDATE webhook_events SET received_at = $2 WHERE id = $1", id, now.Add(-age))
+		require.NoError(t, err)
+	}
+
+	var order []string
+	for range 3 {
+		event, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: registerWorker(t)})
+		require.NoError(t, err)
+		order = append(order, event.EventID)
+	}
+
+	assert.Equal(t, []string{"evt_oldest", "evt_middle", "evt_newest"}, order)
+}
+
+func TestClaimNextWebhook_SkipsUnclaimableEvents(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T, event sqlc.WebhookEvent)
+		params sqlc.ClaimNextWebhookParams
+	}{
+		{
+			name: "already processing",
+			setup: func(t *testing.T, event sqlc.WebhookEvent) {
+				_, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: registerWorker(t)})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not due for retry",
+			setup: func(t *testing.T, event sqlc.WebhookEvent) {
+				workerID := registerWorker(t)
+				_, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+				require.NoError(t, err)
+				_, err = store.RetryWebhook(ctx, sqlc.RetryWebhookParams{
+					ID:            event.ID,
+					ClaimedBy:     workerID,
+					NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+				})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "type paused",
+			setup: func(t *testing.T, event sqlc.WebhookEvent) {
+				_, err := store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{Scope: "payment.refunded", Paused: true})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "everything paused",
+			setup: func(t *testing.T, event sqlc.WebhookEvent) {
+				_, err := store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{Scope: "*", Paused: true})
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "type excluded",
+			params: sqlc.ClaimNextWebhookParams{ExcludedTypes: []string{"payment.refunded"}},
+		},
+		{
+			name:   "other tenant's turn",
+			params: sqlc.ClaimNextWebhookParams{PartitionBy: "tenant", Partition: "globex"},
+		},
+		{
+			name:   "other type's turn",
+			params: sqlc.ClaimNextWebhookParams{PartitionBy: "type", Partition: "payment.completed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetDB(t)
+			event := createEvent(t, "acme", "evt_1", "payment.refunded")
+			if tt.setup != nil {
+				tt.setup(t, event)
+			}
+
+			params := tt.params
+			params.ClaimedBy = registerWorker(t)
+			_, err := store.ClaimNextWebhook(ctx, params)
+
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+		})
+	}
+}
+
+func TestReclaimExpiredWebhooks(t *testing.T) {
+	resetDB(t)
+	ctx := context.Background()
+	live, stopped := registerWorker(t), registerWorker(t)
+
+	createEvent(t, "default", "evt_1", "payment.completed")
+	createEvent(t, "default", "evt_2", "payment.completed")
+	held, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: live})
+	require.NoError(t, err)
+	orphaned, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: stopped})
+	require.NoError(t, err)
+
+	const hour = float64(time.Hour / time.Second)
+	reclaimed, err := store.ReclaimExpiredWebhooks(ctx, hour)
+	require.NoError(t, err)
+	assert.Empty(t, reclaimed)
+
+	_, err = store.StopWorker(ctx, stopped)
+	require.NoError(t, err)
+	reclaimed, err = store.ReclaimExpiredWebhooks(ctx, hour)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+	assert.Equal(t, orphaned.ID, reclaimed[0].ID)
+	assert.Equal(t, db.ReceivedStatus, reclaimed[0].Status)
+
+	renewed, err := store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: held.ID, ClaimedBy: live})
+	require.NoError(t, err)
+	assert.True(t, renewed.ClaimedAt.Time.After(held.ClaimedAt.Time))
+
+	reclaimed, err = store.ReclaimExpiredWebhooks(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+	assert.Equal(t, held.ID, reclaimed[0].ID)
+
+	_, err = store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: held.ID, ClaimedBy: live})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// The old claimer can no longer settle it, even once someone else has
+	// claimed it again.
+	again, err := store.ClaimNextWebhook(ctx, sqlc.ClaimNextWebhookParams{ClaimedBy: registerWorker(t)})
+	require.NoError(t, err)
+	_, err = store.MarkWebhookDone(ctx, sqlc.MarkWebhookDoneParams{ID: again.ID, ClaimedBy: live})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	lastError := "stale"
+	_, err = store.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{ID: again.ID, ClaimedBy: live, LastError: &lastError})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = store.RetryWebhook(ctx, sqlc.RetryWebhookParams{ID: again.ID, ClaimedBy: live, LastError: &lastError})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
@@ -0,0 +1,172 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+	"worker-pool/api"
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebhookService(t *testing.T) *services.WebhookService {
+	t.Helper()
+	validator, err := services.NewPaymentValidator(config.DefaultValidationConfig())
+	require.NoError(t, err)
+	return services.NewWebhookService(store, validator)
+}
+
+func tenantContext(tenant string) context.Context {
+	return services.WithPrincipal(context.Background(), services.Principal{TenantID: tenant})
+}
+
+func paymentWebhook(eventID, eventType string) api.WebhookPaymentJSONRequestBody {
+	return api.WebhookPaymentJSONRequestBody{
+		EventId:    eventID,
+		Type:       eventType,
+		Amount:     "5000",
+		Currency:   "NGN",
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// recordAttempt stores an attempt the way the worker pool does after running
+// the handler for a claimed event.
+func recordAttempt(t *testing.T, event sqlc.WebhookEvent, outcome string, reason *string) {
+	t.Helper()
+	now := time.Now()
+	_, err := store.CreateWebhookAttempt(context.Background(), sqlc.CreateWebhookAttemptParams{
+		WebhookEventID: event.ID,
+		Attempt:        event.Attempts,
+		WorkerID:       uuid.UUID(event.ClaimedBy.Bytes).String(),
+		Host:           "integration",
+		StartedAt:      pgtype.Timestamptz{Time: now.Add(-10 * time.Millisecond), Valid: true},
+		FinishedAt:     pgtype.Timestamptz{Time: now, Valid: true},
+		Outcome:        outcome,
+		Reason:         reason,
+		DurationMs:     10,
+	})
+	require.NoError(t, err)
+}
+
+func TestPipeline_IngestClaimDone(t *testing.T) {
+	resetDB(t)
+	svc := newWebhookService(t)
+	ctx := tenantContext("acme")
+
+	require.NoError(t, svc.ProcessPaymentWebhook(ctx, paymentWebhook("evt_1", "payment.completed")))
+
+	details, err := svc.GetEvent(ctx, "evt_1")
+	require.NoError(t, err)
+	assert.Equal(t, db.ReceivedStatus, details.Event.Status)
+	assert.Equal(t, "acme", details.Event.TenantID)
+
+	workerID := registerWorker(t)
+	event, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.Equal(t, details.Event.ID, event.ID)
+	assert.Equal(t, db.ProcessingStatus, event.Status)
+	assert.EqualValues(t, 1, event.Attempts)
+	assert.Equal(t, workerID, uuid.UUID(event.ClaimedBy.Bytes))
+	assert.True(t, event.ClaimedAt.Valid)
+
+	recordAttempt(t, event, db.AttemptSucceeded, nil)
+	_, err = store.MarkWebhookDone(context.Background(), sqlc.MarkWebhookDoneParams{ID: event.ID, ClaimedBy: workerID})
+	require.NoError(t, err)
+
+	details, err = svc.GetEvent(ctx, "evt_1")
+	require.NoError(t, err)
+	assert.Equal(t, db.DoneStatus, details.Event.Status)
+	assert.True(t, details.Event.ProcessedAt.Valid)
+	require.Len(t, details.Attempts, 1)
+	assert.Equal(t, db.AttemptSucceeded, details.Attempts[0].Outcome)
+	assert.False(t, details.Paused)
+
+	stats, err := store.GetQueueStats(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, stats.Depth)
+}
+
+func TestPipeline_RetryThenFail(t *testing.T) {
+	resetDB(t)
+	svc := newWebhookService(t)
+	ctx := tenantContext("acme")
+	require.NoError(t, svc.ProcessPaymentWebhook(ctx, paymentWebhook("evt_1", "payment.failed")))
+
+	firstWorker := registerWorker(t)
+	first, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: firstWorker})
+	require.NoError(t, err)
+	reason := db.AttemptReasonError
+	recordAttempt(t, first, db.AttemptRetrying, &reason)
+	lastError := "upstream unavailable"
+	_, err = store.RetryWebhook(context.Background(), sqlc.RetryWebhookParams{
+		ID:            first.ID,
+		ClaimedBy:     firstWorker,
+		LastError:     &lastError,
+		NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	require.NoError(t, err)
+
+	secondWorker := registerWorker(t)
+	second, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: secondWorker})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.EqualValues(t, 2, second.Attempts)
+	recordAttempt(t, second, db.AttemptFailed, &reason)
+	_, err = store.MarkWebhookFailed(context.Background(), sqlc.MarkWebhookFailedParams{ID: second.ID, ClaimedBy: secondWorker, LastError: &lastError})
+	require.NoError(t, err)
+
+	details, err := svc.GetEvent(ctx, "evt_1")
+	require.NoError(t, err)
+	assert.Equal(t, db.FailedStatus, details.Event.Status)
+	require.NotNil(t, details.Event.LastError)
+	assert.Equal(t, lastError, *details.Event.LastError)
+	require.Len(t, details.Attempts, 2)
+	assert.Equal(t, db.AttemptRetrying, details.Attempts[0].Outcome)
+	assert.Equal(t, db.AttemptFailed, details.Attempts[1].Outcome)
+	require.NotNil(t, details.Attempts[1].Reason)
+	assert.Equal(t, db.AttemptReasonError, *details.Attempts[1].Reason)
+}
+
+func TestPipeline_ReleasedEventIsClaimedAgain(t *testing.T) {
+	resetDB(t)
+	require.NoError(t, newWebhookService(t).ProcessPaymentWebhook(tenantContext("acme"), paymentWebhook("evt_1", "payment.pending")))
+
+	workerID := registerWorker(t)
+	claimed, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: workerID})
+	require.NoError(t, err)
+	released, err := store.ReleaseWebhook(context.Background(), sqlc.ReleaseWebhookParams{ID: claimed.ID, ClaimedBy: workerID})
+	require.NoError(t, err)
+	assert.Equal(t, db.ReceivedStatus, released.Status)
+	assert.Zero(t, released.Attempts)
+	assert.False(t, released.ClaimedBy.Valid)
+
+	again, err := store.ClaimNextWebhook(context.Background(), sqlc.ClaimNextWebhookParams{ClaimedBy: registerWorker(t)})
+	require.NoError(t, err)
+	assert.Equal(t, claimed.ID, again.ID)
+	assert.EqualValues(t, 1, again.Attempts)
+}
+
+func TestPipeline_DuplicateEventIDsAreScopedToTenant(t *testing.T) {
+	resetDB(t)
+	svc := newWebhookService(t)
+	req := paymentWebhook("evt_1", "payment.completed")
+
+	require.NoError(t, svc.ProcessPaymentWebhook(tenantContext("acme"), req))
+	assert.Error(t, svc.ProcessPaymentWebhook(tenantContext("acme"), req))
+	require.NoError(t, svc.ProcessPaymentWebhook(tenantContext("globex"), req))
+
+	var count int
+	require.NoError(t, pool.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM webhook_events WHERE event_id = $1", req.EventId).Scan(&count))
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,16 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// ChaosFaults counts faults the worker pool's chaos mode injected. It stays
+// at zero unless chaos is enabled.
+var ChaosFaults = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "chaos",
+	Name:      "faults_total",
+	Help:      "Faults injected into event processing by chaos mode, by event type and fault.",
+}, []string{"type", "fault"})
+
+func init() {
+	Registry.MustRegister(ChaosFaults)
+}
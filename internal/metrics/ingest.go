@@ -0,0 +1,62 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Ingest backpressure metrics, updated by the API server each time it
+// refreshes its view of the queue.
+var (
+	IngestQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "queue_depth",
+		Help:      "Received events waiting for a worker, excluding paused types.",
+	})
+	IngestOldestAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "oldest_received_age_seconds",
+		Help:      "Age of the oldest received event waiting for a worker.",
+	})
+	IngestMaxQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "max_queue_depth",
+		Help:      "Configured queue depth above which ingest is rejected; 0 when disabled.",
+	})
+	IngestMaxOldestAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "max_oldest_age_seconds",
+		Help:      "Configured oldest-event age above which ingest is rejected; 0 when disabled.",
+	})
+	IngestBackpressure = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "backpressure_active",
+		Help:      "1 while ingest is rejecting non-priority events, 0 otherwise.",
+	})
+	IngestTenantQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "tenant_queue_depth",
+		Help:      "Received events waiting for a worker per tenant, excluding paused types.",
+	}, []string{"tenant"})
+	IngestRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ingest",
+		Name:      "rejected_total",
+		Help:      "Webhooks rejected because of backpressure or a tenant quota, by the threshold that tripped.",
+	}, []string{"reason", "tenant"})
+)
+
+func init() {
+	Registry.MustRegister(
+		IngestQueueDepth,
+		IngestOldestAge,
+		IngestMaxQueueDepth,
+		IngestMaxOldestAge,
+		IngestBackpressure,
+		IngestTenantQueueDepth,
+		IngestRejected,
+	)
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Per-tenant event throughput. The tenant label is bounded by the number of
+// tenants API keys are issued for.
+var (
+	WebhooksReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "received_total",
+		Help:      "Webhook events accepted at ingest.",
+	}, []string{"tenant"})
+	WebhooksProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "attempts_total",
+		Help:      "Processing attempts finished by the worker pool, by outcome.",
+	}, []string{"tenant", "outcome"})
+	WebhookPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "panics_total",
+		Help:      "Handler panics recovered by the worker pool. Each is also counted as a failed attempt.",
+	}, []string{"tenant", "type"})
+	WebhookTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "timeouts_total",
+		Help:      "Processing attempts that ran past their handler timeout. Each is also counted as a failed attempt.",
+	}, []string{"tenant", "type"})
+	WebhooksReclaimed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "reclaimed_total",
+		Help:      "Processing events returned to the queue because their lease expired or their worker pool died.",
+	}, []string{"tenant"})
+	WebhookHandlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "handler_duration_seconds",
+		Help:      "Time spent in the event handler per attempt, by result: ok, error, panic or timeout.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		WebhooksReceived,
+		WebhooksProcessed,
+		WebhookPanics,
+		WebhookTimeouts,
+		WebhooksReclaimed,
+		WebhookHandlerDuration,
+	)
+}
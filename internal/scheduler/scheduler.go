@@ -0,0 +1,126 @@
+// Package scheduler decides which partition of the backlog, a tenant or an
+// event type, the worker pool claims from next.
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"worker-pool/internal/config"
+)
+
+// Partition is a tenant or event type with claimable events.
+type Partition struct {
+	Key   string
+	Depth int64
+}
+
+// Scheduler picks whose turn it is. Pick is only called with a non-empty
+// backlog and must return one of its keys. Implementations are safe for
+// concurrent use by every worker in a pool.
+type Scheduler interface {
+	Pick(backlog []Partition) string
+}
+
+// New returns the scheduler for a claim strategy. FIFO has none: the claim
+// query takes the oldest event without partitioning, so New returns nil.
+func New(strategy string, weights map[string]int) (Scheduler, error) {
+	switch strategy {
+	case config.ClaimFIFO:
+		return nil, nil
+	case config.ClaimRoundRobin:
+		return &RoundRobin{}, nil
+	case config.ClaimDRR:
+		return NewDRR(weights), nil
+	default:
+		return nil, fmt.Errorf("unknown claim strategy %q", strategy)
+	}
+}
+
+// RoundRobin gives every partition with work one claim in turn, in key
+// order.
+type RoundRobin struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (r *RoundRobin) Pick(backlog []Partition) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := sortedKeys(backlog)
+	next := keys[0]
+	for _, key := range keys {
+		if key > r.last {
+			next = key
+			break
+		}
+	}
+	r.last = next
+	return next
+}
+
+// DRR is deficit round-robin with a unit cost per event: each time a
+// partition's turn comes round it is credited its weight, and it keeps the
+// turn while it has credit. A partition with weight 4 gets four claims for
+// every one of a partition with weight 1. Credit is dropped when a
+// partition's backlog empties, so idle partitions cannot bank turns.
+type DRR struct {
+	weights map[string]int
+
+	mu      sync.Mutex
+	current string
+	deficit map[string]int
+}
+
+func NewDRR(weights map[string]int) *DRR {
+	return &DRR{weights: weights, deficit: make(map[string]int)}
+}
+
+func (d *DRR) weight(key string) int {
+	if w, ok := d.weights[key]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (d *DRR) Pick(backlog []Partition) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := sortedKeys(backlog)
+	active := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		active[key] = struct{}{}
+	}
+	for key := range d.deficit {
+		if _, ok := active[key]; !ok {
+			delete(d.deficit, key)
+		}
+	}
+
+	if _, ok := active[d.current]; ok && d.deficit[d.current] > 0 {
+		d.deficit[d.current]--
+		return d.current
+	}
+
+	next := keys[0]
+	for _, key := range keys {
+		if key > d.current {
+			next = key
+			break
+		}
+	}
+	d.current = next
+	d.deficit[next] += d.weight(next) - 1
+	return next
+}
+
+func sortedKeys(backlog []Partition) []string {
+	keys := make([]string, len(backlog))
+	for i, p := range backlog {
+		keys[i] = p.Key
+	}
+	sort.Strings(keys)
+	return keys
+}
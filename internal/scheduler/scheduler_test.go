@@ -0,0 +1,157 @@
+package scheduler_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+	"worker-pool/internal/config"
+	"worker-pool/internal/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func backlog(keys ...string) []scheduler.Partition {
+	out := make([]scheduler.Partition, len(keys))
+	for i, key := range keys {
+		out[i] = scheduler.Partition{Key: key, Depth: 1}
+	}
+	return out
+}
+
+func picks(s scheduler.Scheduler, b []scheduler.Partition, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = s.Pick(b)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	s, err := scheduler.New(config.ClaimFIFO, nil)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	s, err = scheduler.New(config.ClaimRoundRobin, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &scheduler.RoundRobin{}, s)
+
+	_, err = scheduler.New("lottery", nil)
+	assert.ErrorContains(t, err, `unknown claim strategy "lottery"`)
+}
+
+func TestRoundRobin(t *testing.T) {
+	rr := &scheduler.RoundRobin{}
+
+	assert.Equal(t, []string{"a", "b", "c", "a"}, picks(rr, backlog("c", "a", "b"), 4))
+	// b drained: the turn passes over it.
+	assert.Equal(t, []string{"c", "a", "c"}, picks(rr, backlog("a", "c"), 3))
+}
+
+func TestDRR_Weights(t *testing.T) {
+	drr := scheduler.NewDRR(map[string]int{"a": 3})
+
+	assert.Equal(t, []string{"a", "a", "a", "b", "a", "a", "a", "b"}, picks(drr, backlog("a", "b"), 8))
+}
+
+func TestDRR_DropsCreditWhenIdle(t *testing.T) {
+	drr := scheduler.NewDRR(map[string]int{"a": 3})
+
+	assert.Equal(t, "a", drr.Pick(backlog("a", "b")))
+	// a drains with credit left; when it has work again it starts a fresh turn.
+	assert.Equal(t, "b", drr.Pick(backlog("b")))
+	assert.Equal(t, []string{"a", "a", "a", "b"}, picks(drr, backlog("a", "b"), 4))
+}
+
+// burst is the backlog behind the scheduling benchmark: a loadsim-sized
+// burst of one type received just ahead of a trickle of the others.
+func burst() []string {
+	var events []string
+	for range 1000 {
+		events = append(events, "payment.pending")
+	}
+	for range 50 {
+		events = append(events, "payment.completed", "payment.failed", "payment.refunded")
+	}
+	return events
+}
+
+// simulate drains events, in received order, through a pool of workers
+// taking serviceTime per event, and returns each type's latencies from the
+// start of the burst to the end of processing.
+func simulate(s scheduler.Scheduler, events []string, workers int, serviceTime time.Duration) map[string][]time.Duration {
+	queues := map[string]int{}
+	for _, eventType := range events {
+		queues[eventType]++
+	}
+	fifo := slices.Clone(events)
+
+	latencies := map[string][]time.Duration{}
+	for claimed := 0; claimed < len(events); claimed++ {
+		var eventType string
+		if s == nil {
+			eventType, fifo = fifo[0], fifo[1:]
+		} else {
+			var b []scheduler.Partition
+			for key, depth := range queues {
+				if depth > 0 {
+					b = append(b, scheduler.Partition{Key: key, Depth: int64(depth)})
+				}
+			}
+			eventType = s.Pick(b)
+		}
+		queues[eventType]--
+
+		finished := time.Duration(claimed/workers+1) * serviceTime
+		latencies[eventType] = append(latencies[eventType], finished)
+	}
+	return latencies
+}
+
+func percentile(d []time.Duration, p float64) time.Duration {
+	sorted := slices.Clone(d)
+	slices.Sort(sorted)
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+func TestFairStrategiesUnblockOtherTypes(t *testing.T) {
+	fifo := simulate(nil, burst(), 5, 10*time.Millisecond)
+	rr := simulate(&scheduler.RoundRobin{}, burst(), 5, 10*time.Millisecond)
+
+	assert.Greater(t, percentile(fifo["payment.completed"], 0.5), 2*time.Second)
+	assert.Less(t, percentile(rr["payment.completed"], 0.99), 500*time.Millisecond)
+	// The burst pays for it, but the backlog as a whole drains no later.
+	assert.Equal(t, percentile(fifo["payment.refunded"], 1), percentile(rr["payment.pending"], 1))
+}
+
+// BenchmarkClaimStrategies reports per-type latency percentiles for each
+// strategy draining the mixed burst with 5 workers at 10ms per event:
+//
+//	go test ./internal/scheduler -run '^$' -bench ClaimStrategies
+func BenchmarkClaimStrategies(b *testing.B) {
+	strategies := []struct {
+		name      string
+		scheduler func() scheduler.Scheduler
+	}{
+		{config.ClaimFIFO, func() scheduler.Scheduler { return nil }},
+		{config.ClaimRoundRobin, func() scheduler.Scheduler { return &scheduler.RoundRobin{} }},
+		{config.ClaimDRR, func() scheduler.Scheduler {
+			return scheduler.NewDRR(map[string]int{"payment.completed": 4, "payment.refunded": 2})
+		}},
+	}
+
+	events := burst()
+	for _, strategy := range strategies {
+		b.Run(strategy.name, func(b *testing.B) {
+			var latencies map[string][]time.Duration
+			for b.Loop() {
+				latencies = simulate(strategy.scheduler(), events, 5, 10*time.Millisecond)
+			}
+			for eventType, d := range latencies {
+				b.ReportMetric(float64(percentile(d, 0.5).Milliseconds()), fmt.Sprintf("p50_ms/%s", eventType))
+				b.ReportMetric(float64(percentile(d, 0.99).Milliseconds()), fmt.Sprintf("p99_ms/%s", eventType))
+			}
+		})
+	}
+}
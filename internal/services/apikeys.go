@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// API key scopes. A key may only call operations whose scope it holds.
+const (
+	ScopeIngest = "ingest"
+	ScopeRead   = "read"
+	ScopeAdmin  = "admin"
+)
+
+const (
+	apiKeyPrefix    = "wpk_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+
+	// apiKeyCacheTTL bounds how long a revoked key keeps working on a
+	// server that has it cached.
+	apiKeyCacheTTL = 30 * time.Second
+	// apiKeyTouchInterval throttles last_used_at writes to one per key per
+	// interval, so ingest traffic does not turn into an UPDATE per request.
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// Principal is the caller behind an authenticated request.
+type Principal struct {
+	KeyID    uuid.UUID
+	Name     string
+	Prefix   string
+	TenantID string
+	Scopes   []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// IsOperator reports whether the caller holds an operator key, the only
+// kind allowed to act on the queue and workers of every tenant.
+func (p Principal) IsOperator() bool {
+	return p.TenantID == OperatorTenant && p.HasScope(ScopeAdmin)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the authenticated caller, if there is one.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// DefaultTenant owns anonymous requests and every event stored before
+// tenancy was introduced.
+const DefaultTenant = "default"
+
+// OperatorTenant is the tenant of operator keys. The admin scope pauses the
+// whole queue and lists every tenant's in-flight events, so it is only
+// granted to keys issued for OperatorTenant, and those keys hold no other
+// scope.
+const OperatorTenant = "*"
+
+// TenantFrom is the tenant a request acts for: its API key's tenant, or
+// DefaultTenant when the request is anonymous.
+func TenantFrom(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.TenantID
+	}
+	return DefaultTenant
+}
+
+type APIKeyService struct {
+	store db.Store
+	now   func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+type cachedKey struct {
+	key       sqlc.ApiKey
+	fetchedAt time.Time
+	touchedAt time.Time
+}
+
+func NewAPIKeyService(store db.Store) *APIKeyService {
+	return &APIKeyService{
+		store: store,
+		now:   time.Now,
+		cache: make(map[string]cachedKey),
+	}
+}
+
+// Create issues a new key. The plaintext is returned once and only its hash
+// is stored.
+func (s *APIKeyService) Create(ctx context.Context, name, tenantID string, scopes []string) (string, sqlc.ApiKey, error) {
+	if name == "" || tenantID == "" {
+		return "", sqlc.ApiKey{}, errors.New("name and tenant are required")
+	}
+	if len(scopes) == 0 {
+		return "", sqlc.ApiKey{}, errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if scope != ScopeIngest && scope != ScopeRead && scope != ScopeAdmin {
+			return "", sqlc.ApiKey{}, fmt.Errorf("unknown scope %q", scope)
+		}
+		if (scope == ScopeAdmin) != (tenantID == OperatorTenant) {
+			return "", sqlc.ApiKey{}, fmt.Errorf("the %s scope is only for operator keys (tenant %q), which hold no other scope", ScopeAdmin, OperatorTenant)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", sqlc.ApiKey{}, fmt.Errorf("generate key: %w", err)
+	}
+	plaintext := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key, err := s.store.CreateAPIKey(ctx, sqlc.CreateAPIKeyParams{
+		Name:     name,
+		Prefix:   plaintext[:apiKeyPrefixLen],
+		KeyHash:  hashAPIKey(plaintext),
+		TenantID: tenantID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return "", sqlc.ApiKey{}, fmt.Errorf("create api key: %w", err)
+	}
+	return plaintext, key, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) (sqlc.ApiKey, error) {
+	key, err := s.store.RevokeAPIKey(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.ApiKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return sqlc.ApiKey{}, fmt.Errorf("revoke api key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context) ([]sqlc.ApiKey, error) {
+	keys, err := s.store.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Authenticate resolves a presented key to its principal. Lookups are
+// cached for apiKeyCacheTTL; unknown keys are never cached.
+func (s *APIKeyService) Authenticate(ctx context.Context, plaintext string) (Principal, error) {
+	hash := hashAPIKey(plaintext)
+	now := s.now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[hash]
+	s.mu.Unlock()
+
+	if !ok || now.Sub(entry.fetchedAt) > apiKeyCacheTTL {
+		key, err := s.store.GetActiveAPIKeyByHash(ctx, hash)
+		if errors.Is(err, pgx.ErrNoRows) {
+			s.mu.Lock()
+			delete(s.cache, hash)
+			s.mu.Unlock()
+			return Principal{}, ErrInvalidAPIKey
+		}
+		if err != nil {
+			return Principal{}, fmt.Errorf("get api key: %w", err)
+		}
+		entry = cachedKey{key: key, fetchedAt: now, touchedAt: entry.touchedAt}
+	}
+
+	if now.Sub(entry.touchedAt) > apiKeyTouchInterval {
+		if err := s.store.TouchAPIKey(ctx, entry.key.ID); err != nil {
+			log.Warn().Err(err).Str("api_key", entry.key.Prefix).Msg("Failed to record API key use")
+		} else {
+			entry.touchedAt = now
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[hash] = entry
+	s.mu.Unlock()
+
+	return Principal{
+		KeyID:    entry.key.ID,
+		Name:     entry.key.Name,
+		Prefix:   entry.key.Prefix,
+		TenantID: entry.key.TenantID,
+		Scopes:   entry.key.Scopes,
+	}, nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
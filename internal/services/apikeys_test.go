@@ -0,0 +1,96 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"worker-pool/internal/db/dbtest"
+	"worker-pool/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// touchCountingStore records every TouchAPIKey call, which the in-memory
+// store alone can't tell apart from a single one.
+type touchCountingStore struct {
+	*dbtest.Store
+	touchedKeys []uuid.UUID
+}
+
+func (s *touchCountingStore) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	s.touchedKeys = append(s.touchedKeys, id)
+	return s.Store.TouchAPIKey(ctx, id)
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	store := &touchCountingStore{Store: dbtest.NewStore()}
+	keys := services.NewAPIKeyService(store)
+	ctx := context.Background()
+
+	plaintext, key, err := keys.Create(ctx, "stripe ingest", "acme", []string{services.ScopeIngest})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, key.Prefix))
+	assert.NotContains(t, key.KeyHash, plaintext)
+
+	principal, err := keys.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, principal.KeyID)
+	assert.Equal(t, "acme", principal.TenantID)
+	assert.True(t, principal.HasScope(services.ScopeIngest))
+	assert.False(t, principal.HasScope(services.ScopeAdmin))
+
+	// A second use within the touch interval does not write last_used_at again.
+	_, err = keys.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Len(t, store.touchedKeys, 1)
+
+	_, err = keys.Authenticate(ctx, plaintext+"x")
+	assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	store := dbtest.NewStore()
+	keys := services.NewAPIKeyService(store)
+	ctx := context.Background()
+
+	plaintext, key, err := keys.Create(ctx, "dashboard", "acme", []string{services.ScopeRead})
+	require.NoError(t, err)
+
+	revoked, err := keys.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.RevokedAt.Valid)
+
+	// A fresh service has nothing cached, as after the cache TTL.
+	_, err = services.NewAPIKeyService(store).Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, services.ErrInvalidAPIKey)
+
+	again, err := keys.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+}
+
+func TestAPIKeyService_CreateValidation(t *testing.T) {
+	keys := services.NewAPIKeyService(dbtest.NewStore())
+	ctx := context.Background()
+
+	_, _, err := keys.Create(ctx, "", "acme", []string{services.ScopeRead})
+	assert.Error(t, err)
+
+	_, _, err = keys.Create(ctx, "dashboard", "acme", nil)
+	assert.Error(t, err)
+
+	_, _, err = keys.Create(ctx, "dashboard", "acme", []string{"superuser"})
+	assert.ErrorContains(t, err, `unknown scope "superuser"`)
+
+	_, _, err = keys.Create(ctx, "tenant admin", "acme", []string{services.ScopeAdmin})
+	assert.ErrorContains(t, err, "only for operator keys")
+
+	_, _, err = keys.Create(ctx, "operator", services.OperatorTenant, []string{services.ScopeAdmin, services.ScopeIngest})
+	assert.ErrorContains(t, err, "only for operator keys")
+
+	_, key, err := keys.Create(ctx, "operator", services.OperatorTenant, []string{services.ScopeAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, services.OperatorTenant, key.TenantID)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	"worker-pool/internal/metrics"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Reasons ingest is being rejected, used as the metrics label.
+const (
+	ReasonQueueDepth  = "queue_depth"
+	ReasonOldestAge   = "oldest_age"
+	ReasonTenantQuota = "tenant_quota"
+)
+
+// Backpressure caches the queue stats and decides whether to turn new
+// events away while workers catch up.
+type Backpressure struct {
+	store    db.Store
+	cfg      config.BackpressureConfig
+	priority map[string]struct{}
+
+	// reason is empty while ingest is open.
+	reason atomic.Pointer[string]
+	// overQuota holds the tenants currently past their backlog quota.
+	overQuota atomic.Pointer[map[string]struct{}]
+}
+
+func NewBackpressure(store db.Store, cfg config.BackpressureConfig) *Backpressure {
+	priority := make(map[string]struct{}, len(cfg.PriorityTypes))
+	for _, t := range cfg.PriorityTypes {
+		priority[t] = struct{}{}
+	}
+
+	metrics.IngestMaxQueueDepth.Set(float64(cfg.MaxQueueDepth))
+	metrics.IngestMaxOldestAge.Set(cfg.MaxOldestAge.Seconds())
+
+	return &Backpressure{store: store, cfg: cfg, priority: priority}
+}
+
+func (b *Backpressure) Enabled() bool {
+	return b.cfg.MaxQueueDepth > 0 || b.cfg.MaxOldestAge > 0 || b.tenantQuotasEnabled()
+}
+
+func (b *Backpressure) tenantQuotasEnabled() bool {
+	return b.cfg.TenantMaxQueueDepth > 0 || len(b.cfg.TenantQuotas) > 0
+}
+
+// tenantQuota is the backlog tenant may have before its ingest is refused,
+// or zero for no cap.
+func (b *Backpressure) tenantQuota(tenant string) int64 {
+	if quota, ok := b.cfg.TenantQuotas[tenant]; ok {
+		return int64(quota)
+	}
+	return b.cfg.TenantMaxQueueDepth
+}
+
+// Run refreshes the cached stats every CheckInterval until ctx is cancelled.
+// A failed refresh leaves ingest open: the database being slow to answer a
+// stats query is no reason to stop accepting events.
+func (b *Backpressure) Run(ctx context.Context) error {
+	if !b.Enabled() {
+		return nil
+	}
+
+	ticker := time.NewTicker(b.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Failed to refresh queue stats; accepting ingest")
+			b.set("")
+			b.overQuota.Store(nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reads the queue stats once and updates the cached decision.
+func (b *Backpressure) Refresh(ctx context.Context) error {
+	stats, err := b.store.GetQueueStats(ctx)
+	if err != nil {
+		return fmt.Errorf("get queue stats: %w", err)
+	}
+
+	metrics.IngestQueueDepth.Set(float64(stats.Depth))
+	metrics.IngestOldestAge.Set(stats.OldestAgeSeconds)
+
+	reason := ""
+	switch {
+	case b.cfg.MaxQueueDepth > 0 && stats.Depth > b.cfg.MaxQueueDepth:
+		reason = ReasonQueueDepth
+	case b.cfg.MaxOldestAge > 0 && stats.OldestAgeSeconds > b.cfg.MaxOldestAge.Seconds():
+		reason = ReasonOldestAge
+	}
+
+	if prev := b.current(); prev != reason {
+		event := log.Info()
+		if reason != "" {
+			event = log.Warn()
+		}
+		event.
+			Str("reason", reason).
+			Int64("depth", stats.Depth).
+			Float64("oldest_age_seconds", stats.OldestAgeSeconds).
+			Bool("active", reason != "").
+			Msg("Ingest backpressure changed")
+	}
+	b.set(reason)
+
+	if b.tenantQuotasEnabled() {
+		return b.refreshTenants(ctx)
+	}
+	return nil
+}
+
+func (b *Backpressure) refreshTenants(ctx context.Context) error {
+	rows, err := b.store.GetTenantQueueStats(ctx)
+	if err != nil {
+		return fmt.Errorf("get tenant queue stats: %w", err)
+	}
+
+	// Reset so tenants whose backlog drained do not keep their last depth.
+	metrics.IngestTenantQueueDepth.Reset()
+	over := make(map[string]struct{})
+	for _, row := range rows {
+		metrics.IngestTenantQueueDepth.WithLabelValues(row.TenantID).Set(float64(row.Depth))
+		if quota := b.tenantQuota(row.TenantID); quota > 0 && row.Depth > quota {
+			over[row.TenantID] = struct{}{}
+		}
+	}
+
+	prev := b.overQuota.Load()
+	for tenant := range over {
+		if prev == nil || !hasKey(*prev, tenant) {
+			log.Warn().Str("tenant", tenant).Int64("quota", b.tenantQuota(tenant)).Msg("Tenant over ingest quota")
+		}
+	}
+	if prev != nil {
+		for tenant := range *prev {
+			if !hasKey(over, tenant) {
+				log.Info().Str("tenant", tenant).Msg("Tenant back under ingest quota")
+			}
+		}
+	}
+	b.overQuota.Store(&over)
+	return nil
+}
+
+func hasKey(m map[string]struct{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// Admit reports whether an event of eventType from tenant should be
+// accepted. When it returns false, reason names the threshold that tripped.
+// A tenant over its quota is refused even for priority types: the quota is
+// what keeps one tenant from crowding out the rest.
+func (b *Backpressure) Admit(tenant, eventType string) (bool, string) {
+	if over := b.overQuota.Load(); over != nil && hasKey(*over, tenant) {
+		metrics.IngestRejected.WithLabelValues(ReasonTenantQuota, tenant).Inc()
+		return false, ReasonTenantQuota
+	}
+
+	reason := b.current()
+	if reason == "" {
+		return true, ""
+	}
+	if _, ok := b.priority[eventType]; ok {
+		return true, ""
+	}
+	metrics.IngestRejected.WithLabelValues(reason, tenant).Inc()
+	return false, reason
+}
+
+// RetryAfter is how long rejected senders are told to wait.
+func (b *Backpressure) RetryAfter() time.Duration {
+	return b.cfg.RetryAfter
+}
+
+func (b *Backpressure) current() string {
+	if r := b.reason.Load(); r != nil {
+		return *r
+	}
+	return ""
+}
+
+func (b *Backpressure) set(reason string) {
+	b.reason.Store(&reason)
+	active := 0.0
+	if reason != "" {
+		active = 1
+	}
+	metrics.IngestBackpressure.Set(active)
+}
@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"worker-pool/internal/config"
+	"worker-pool/internal/db/mocks"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpressure(t *testing.T) {
+	cfg := config.BackpressureConfig{
+		MaxQueueDepth: 100,
+		MaxOldestAge:  time.Minute,
+		CheckInterval: time.Second,
+		RetryAfter:    30 * time.Second,
+		PriorityTypes: []string{"payment.refunded"},
+	}
+
+	tests := []struct {
+		name           string
+		stats          sqlc.GetQueueStatsRow
+		eventType      string
+		expectedAdmit  bool
+		expectedReason string
+	}{
+		{"under both thresholds", sqlc.GetQueueStatsRow{Depth: 100, OldestAgeSeconds: 59}, "payment.completed", true, ""},
+		{"queue too deep", sqlc.GetQueueStatsRow{Depth: 101}, "payment.completed", false, services.ReasonQueueDepth},
+		{"oldest event too old", sqlc.GetQueueStatsRow{Depth: 1, OldestAgeSeconds: 61}, "payment.completed", false, services.ReasonOldestAge},
+		{"priority type passes", sqlc.GetQueueStatsRow{Depth: 500}, "payment.refunded", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := mocks.NewStore(t)
+			store.EXPECT().GetQueueStats(mock.Anything).Return(tt.stats, nil)
+			bp := services.NewBackpressure(store, cfg)
+			require.NoError(t, bp.Refresh(context.Background()))
+
+			admit, reason := bp.Admit("acme", tt.eventType)
+
+			assert.Equal(t, tt.expectedAdmit, admit)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestBackpressure_ClearsWhenQueueDrains(t *testing.T) {
+	depth := int64(1000)
+	store := mocks.NewStore(t)
+	store.EXPECT().GetQueueStats(mock.Anything).
+		RunAndReturn(func(ctx context.Context) (sqlc.GetQueueStatsRow, error) {
+			return sqlc.GetQueueStatsRow{Depth: depth}, nil
+		})
+	bp := services.NewBackpressure(store, config.BackpressureConfig{MaxQueueDepth: 10, CheckInterval: time.Second})
+
+	require.NoError(t, bp.Refresh(context.Background()))
+	admit, _ := bp.Admit("acme", "payment.completed")
+	assert.False(t, admit)
+
+	depth = 5
+	require.NoError(t, bp.Refresh(context.Background()))
+	admit, _ = bp.Admit("acme", "payment.completed")
+	assert.True(t, admit)
+}
+
+func TestBackpressure_RefreshError(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().GetQueueStats(mock.Anything).Return(sqlc.GetQueueStatsRow{}, errors.New("db down"))
+	bp := services.NewBackpressure(store, config.BackpressureConfig{MaxQueueDepth: 10, CheckInterval: time.Second})
+
+	err := bp.Refresh(context.Background())
+
+	assert.ErrorContains(t, err, "get queue stats")
+	admit, _ := bp.Admit("acme", "payment.completed")
+	assert.True(t, admit)
+}
+
+func TestBackpressure_Disabled(t *testing.T) {
+	bp := services.NewBackpressure(mocks.NewStore(t), config.Default().Backpressure)
+
+	assert.False(t, bp.Enabled())
+}
+
+func TestBackpressure_TenantQuota(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().GetQueueStats(mock.Anything).Return(sqlc.GetQueueStatsRow{Depth: 320}, nil)
+	store.EXPECT().GetTenantQueueStats(mock.Anything).Return([]sqlc.GetTenantQueueStatsRow{
+		{TenantID: "acme", Depth: 150},
+		{TenantID: "globex", Depth: 150},
+		{TenantID: "initech", Depth: 20},
+	}, nil)
+	bp := services.NewBackpressure(store, config.BackpressureConfig{
+		TenantMaxQueueDepth: 100,
+		TenantQuotas:        map[string]int{"globex": 500},
+		CheckInterval:       time.Second,
+		PriorityTypes:       []string{"payment.refunded"},
+	})
+	require.True(t, bp.Enabled())
+	require.NoError(t, bp.Refresh(context.Background()))
+
+	admit, reason := bp.Admit("acme", "payment.refunded")
+	assert.False(t, admit)
+	assert.Equal(t, services.ReasonTenantQuota, reason)
+
+	admit, _ = bp.Admit("globex", "payment.completed")
+	assert.True(t, admit, "per-tenant override raises the cap")
+
+	admit, _ = bp.Admit("initech", "payment.completed")
+	assert.True(t, admit)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+)
+
+// GlobalScope is the queue control scope that covers every event type.
+const GlobalScope = "*"
+
+// QueueService pauses and resumes processing. Ingest is unaffected: paused
+// events are still accepted and stay received until processing resumes.
+type QueueService struct {
+	store db.Store
+}
+
+func NewQueueService(store db.Store) *QueueService {
+	return &QueueService{store: store}
+}
+
+// Pause stops workers claiming events of eventType, or of every type when
+// eventType is empty.
+func (s *QueueService) Pause(ctx context.Context, eventType string, reason *string) (sqlc.QueueControl, error) {
+	control, err := s.store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{
+		Scope:  scope(eventType),
+		Paused: true,
+		Reason: reason,
+	})
+	if err != nil {
+		return sqlc.QueueControl{}, fmt.Errorf("pause queue: %w", err)
+	}
+	return control, nil
+}
+
+// Resume lifts a pause set by Pause for the same scope. A global resume does
+// not lift per-type pauses.
+func (s *QueueService) Resume(ctx context.Context, eventType string) (sqlc.QueueControl, error) {
+	control, err := s.store.SetQueuePaused(ctx, sqlc.SetQueuePausedParams{
+		Scope:  scope(eventType),
+		Paused: false,
+	})
+	if err != nil {
+		return sqlc.QueueControl{}, fmt.Errorf("resume queue: %w", err)
+	}
+	return control, nil
+}
+
+func (s *QueueService) List(ctx context.Context) ([]sqlc.QueueControl, error) {
+	controls, err := s.store.ListQueueControls(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list queue controls: %w", err)
+	}
+	return controls, nil
+}
+
+// IsPaused reports whether controls stop events of eventType being claimed.
+func IsPaused(controls []sqlc.QueueControl, eventType string) bool {
+	for _, c := range controls {
+		if c.Paused && (c.Scope == GlobalScope || c.Scope == eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func scope(eventType string) string {
+	if eventType == "" {
+		return GlobalScope
+	}
+	return eventType
+}
@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"worker-pool/internal/db/dbtest"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueService_PauseAndResume(t *testing.T) {
+	store := dbtest.NewStore()
+	queue := services.NewQueueService(store)
+	ctx := context.Background()
+	reason := "refund provider incident"
+
+	control, err := queue.Pause(ctx, "payment.refunded", &reason)
+	require.NoError(t, err)
+	assert.Equal(t, "payment.refunded", control.Scope)
+	assert.True(t, control.Paused)
+
+	control, err = queue.Pause(ctx, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, services.GlobalScope, control.Scope)
+
+	control, err = queue.Resume(ctx, "")
+	require.NoError(t, err)
+	assert.False(t, control.Paused)
+
+	controls, err := queue.List(ctx)
+	require.NoError(t, err)
+	assert.True(t, services.IsPaused(controls, "payment.refunded"))
+	assert.False(t, services.IsPaused(controls, "payment.completed"))
+}
+
+func TestIsPaused(t *testing.T) {
+	tests := []struct {
+		name      string
+		controls  []sqlc.QueueControl
+		eventType string
+		expected  bool
+	}{
+		{"no controls", nil, "payment.completed", false},
+		{"global pause", []sqlc.QueueControl{{Scope: "*", Paused: true}}, "payment.completed", true},
+		{"global pause covers untyped events", []sqlc.QueueControl{{Scope: "*", Paused: true}}, "", true},
+		{"type pause", []sqlc.QueueControl{{Scope: "payment.refunded", Paused: true}}, "payment.refunded", true},
+		{"other type paused", []sqlc.QueueControl{{Scope: "payment.refunded", Paused: true}}, "payment.completed", false},
+		{"resumed", []sqlc.QueueControl{{Scope: "payment.refunded", Paused: false}}, "payment.refunded", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, services.IsPaused(tt.controls, tt.eventType))
+		})
+	}
+}
+
+func TestGetEvent_Paused(t *testing.T) {
+	eventType := "payment.refunded"
+	store := dbtest.NewStore()
+	store.PutEvent(sqlc.WebhookEvent{TenantID: services.DefaultTenant, EventID: "evt_1", Type: &eventType})
+	_, err := store.SetQueuePaused(context.Background(), sqlc.SetQueuePausedParams{Scope: eventType, Paused: true})
+	require.NoError(t, err)
+	service := newTestService(t, store)
+
+	details, err := service.GetEvent(context.Background(), "evt_1")
+
+	require.NoError(t, err)
+	assert.True(t, details.Paused)
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+	"worker-pool/api"
+	"worker-pool/internal/config"
+)
+
+var amountPattern = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// ValidationError carries every field-level problem found in a request so
+// the handler can report them together.
+type ValidationError struct {
+	Errors []api.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Errors = append(e.Errors, api.FieldError{Field: field, Message: message})
+}
+
+type PaymentValidator struct {
+	minAmount      *big.Rat
+	maxAmount      *big.Rat
+	allowedTypes   map[string]struct{}
+	maxFutureSkew  time.Duration
+	eventIDPattern *regexp.Regexp
+	now            func() time.Time
+}
+
+func NewPaymentValidator(cfg config.ValidationConfig) (*PaymentValidator, error) {
+	minAmount, ok := new(big.Rat).SetString(cfg.MinAmount)
+	if !ok || minAmount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid min amount %q", cfg.MinAmount)
+	}
+
+	maxAmount, ok := new(big.Rat).SetString(cfg.MaxAmount)
+	if !ok || maxAmount.Cmp(minAmount) < 0 {
+		return nil, fmt.Errorf("invalid max amount %q", cfg.MaxAmount)
+	}
+
+	if len(cfg.AllowedTypes) == 0 {
+		return nil, fmt.Errorf("at least one allowed event type is required")
+	}
+
+	eventIDPattern, err := regexp.Compile(cfg.EventIDPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event id pattern: %w", err)
+	}
+
+	allowedTypes := make(map[string]struct{}, len(cfg.AllowedTypes))
+	for _, t := range cfg.AllowedTypes {
+		allowedTypes[t] = struct{}{}
+	}
+
+	return &PaymentValidator{
+		minAmount:      minAmount,
+		maxAmount:      maxAmount,
+		allowedTypes:   allowedTypes,
+		maxFutureSkew:  cfg.MaxFutureSkew,
+		eventIDPattern: eventIDPattern,
+		now:            time.Now,
+	}, nil
+}
+
+// Validate returns a *ValidationError listing every invalid field, or nil.
+func (v *PaymentValidator) Validate(req api.WebhookPaymentRequest) error {
+	verr := &ValidationError{}
+
+	switch {
+	case req.EventId == "":
+		verr.add("event_id", "is required")
+	case !v.eventIDPattern.MatchString(req.EventId):
+		verr.add("event_id", "must match "+v.eventIDPattern.String())
+	}
+
+	switch {
+	case req.Type == "":
+		verr.add("type", "is required")
+	case !v.typeAllowed(req.Type):
+		verr.add("type", "is not an allowed event type")
+	}
+
+	switch {
+	case req.Amount == "":
+		verr.add("amount", "is required")
+	case !amountPattern.MatchString(req.Amount):
+		verr.add("amount", "must be a non-negative decimal")
+	default:
+		amount, _ := new(big.Rat).SetString(req.Amount)
+		if amount.Cmp(v.minAmount) < 0 || amount.Cmp(v.maxAmount) > 0 {
+			verr.add("amount", fmt.Sprintf("must be between %s and %s",
+				v.minAmount.FloatString(2), v.maxAmount.FloatString(2)))
+		}
+	}
+
+	switch {
+	case req.Currency == "":
+		verr.add("currency", "is required")
+	case !isISO4217(req.Currency):
+		verr.add("currency", "must be an ISO 4217 currency code")
+	}
+
+	switch {
+	case req.OccurredAt.IsZero():
+		verr.add("occurred_at", "is required")
+	case req.OccurredAt.After(v.now().Add(v.maxFutureSkew)):
+		verr.add("occurred_at", fmt.Sprintf("must not be more than %s in the future", v.maxFutureSkew))
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func (v *PaymentValidator) typeAllowed(t string) bool {
+	_, ok := v.allowedTypes[t]
+	return ok
+}
+
+func isISO4217(code string) bool {
+	_, ok := iso4217Currencies[code]
+	return ok
+}
@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+	"worker-pool/api"
+	"worker-pool/internal/config"
+	"worker-pool/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validRequest() api.WebhookPaymentRequest {
+	return api.WebhookPaymentRequest{
+		EventId:    "evt_12345",
+		Type:       "payment.completed",
+		Amount:     "5000.50",
+		Currency:   "NGN",
+		OccurredAt: time.Now().UTC().Add(-time.Minute),
+	}
+}
+
+func TestPaymentValidator_Validate(t *testing.T) {
+	validator, err := services.NewPaymentValidator(config.DefaultValidationConfig())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		mutate         func(*api.WebhookPaymentRequest)
+		expectedFields []string
+	}{
+		{
+			name:   "success - valid request",
+			mutate: func(r *api.WebhookPaymentRequest) {},
+		},
+		{
+			name: "error - missing fields",
+			mutate: func(r *api.WebhookPaymentRequest) {
+				*r = api.WebhookPaymentRequest{}
+			},
+			expectedFields: []string{"event_id", "type", "amount", "currency", "occurred_at"},
+		},
+		{
+			name:           "error - negative amount",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Amount = "-1" },
+			expectedFields: []string{"amount"},
+		},
+		{
+			name:           "error - non-numeric amount",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Amount = "12abc" },
+			expectedFields: []string{"amount"},
+		},
+		{
+			name:           "error - amount above max",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Amount = "1000000000.01" },
+			expectedFields: []string{"amount"},
+		},
+		{
+			name:           "error - unknown currency",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Currency = "ABC" },
+			expectedFields: []string{"currency"},
+		},
+		{
+			name:           "error - lowercase currency",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Currency = "ngn" },
+			expectedFields: []string{"currency"},
+		},
+		{
+			name:           "error - type not allowed",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.Type = "payment.exploded" },
+			expectedFields: []string{"type"},
+		},
+		{
+			name:           "error - occurred_at too far in future",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.OccurredAt = time.Now().Add(time.Hour) },
+			expectedFields: []string{"occurred_at"},
+		},
+		{
+			name:           "error - malformed event_id",
+			mutate:         func(r *api.WebhookPaymentRequest) { r.EventId = "12345 OR 1=1" },
+			expectedFields: []string{"event_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.mutate(&req)
+
+			err := validator.Validate(req)
+
+			if len(tt.expectedFields) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var verr *services.ValidationError
+			require.ErrorAs(t, err, &verr)
+			fields := make([]string, 0, len(verr.Errors))
+			for _, fe := range verr.Errors {
+				fields = append(fields, fe.Field)
+			}
+			assert.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
+
+func TestNewPaymentValidator_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*config.ValidationConfig)
+	}{
+		{name: "negative min", mutate: func(c *config.ValidationConfig) { c.MinAmount = "-1" }},
+		{name: "max below min", mutate: func(c *config.ValidationConfig) { c.MinAmount = "10"; c.MaxAmount = "5" }},
+		{name: "no allowed types", mutate: func(c *config.ValidationConfig) { c.AllowedTypes = nil }},
+		{name: "bad pattern", mutate: func(c *config.ValidationConfig) { c.EventIDPattern = "(" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultValidationConfig()
+			tt.mutate(&cfg)
+
+			_, err := services.NewPaymentValidator(cfg)
+
+			require.Error(t, err)
+		})
+	}
+}
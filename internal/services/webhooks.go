@@ -3,41 +3,104 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"worker-pool/api"
 	"worker-pool/internal/db"
 	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 )
 
+var ErrEventNotFound = errors.New("event not found")
+
+// EventDetails is an event together with its processing history.
+type EventDetails struct {
+	Event    sqlc.WebhookEvent
+	Attempts []sqlc.WebhookAttempt
+	// Paused is set when the event is waiting to be claimed but processing
+	// of its type is paused.
+	Paused bool
+}
+
 type WebhookService struct {
-	store db.Store
+	store     db.Store
+	validator *PaymentValidator
 }
 
-func NewWebhookService(store db.Store) *WebhookService {
+func NewWebhookService(store db.Store, validator *PaymentValidator) *WebhookService {
 	return &WebhookService{
-		store: store,
+		store:     store,
+		validator: validator,
 	}
 }
 
-func (s *WebhookService) ProcessPaymentWebhook(req api.WebhookPaymentJSONRequestBody) error {
+func (s *WebhookService) ProcessPaymentWebhook(ctx context.Context, req api.WebhookPaymentJSONRequestBody) error {
 	log.Info().Str("request", fmt.Sprintf("%+v", req)).
 		Msg("Processing payment webhook data")
 
+	if err := s.validator.Validate(req); err != nil {
+		return err
+	}
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("marshal webhook payload: %w", err)
 	}
 
-	_, err = s.store.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{
-		EventID: req.EventId,
-		Type:    &req.Type,
-		Payload: payload,
+	tenant := TenantFrom(ctx)
+	_, err = s.store.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+		TenantID: tenant,
+		EventID:  req.EventId,
+		Type:     &req.Type,
+		Payload:  payload,
 	})
 
 	if err != nil {
 		return fmt.Errorf("create webhook: %w", err)
 	}
 
+	metrics.WebhooksReceived.WithLabelValues(tenant).Inc()
 	return nil
 }
+
+// GetEvent looks an event up by its provider event ID within the caller's
+// tenant and loads every attempt made at it, oldest first. Another tenant's
+// event is reported as not found.
+func (s *WebhookService) GetEvent(ctx context.Context, eventID string) (EventDetails, error) {
+	event, err := s.store.GetWebhookByEventID(ctx, sqlc.GetWebhookByEventIDParams{
+		TenantID: TenantFrom(ctx),
+		EventID:  eventID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return EventDetails{}, ErrEventNotFound
+	}
+	if err != nil {
+		return EventDetails{}, fmt.Errorf("get webhook: %w", err)
+	}
+
+	attempts, err := s.store.ListWebhookAttempts(ctx, event.ID)
+	if err != nil {
+		return EventDetails{}, fmt.Errorf("list webhook attempts: %w", err)
+	}
+
+	details := EventDetails{Event: event, Attempts: attempts}
+	if event.Status == db.ReceivedStatus {
+		controls, err := s.store.ListQueueControls(ctx)
+		if err != nil {
+			return EventDetails{}, fmt.Errorf("list queue controls: %w", err)
+		}
+		details.Paused = IsPaused(controls, typeOf(event))
+	}
+
+	return details, nil
+}
+
+func typeOf(event sqlc.WebhookEvent) string {
+	if event.Type == nil {
+		return ""
+	}
+	return *event.Type
+}
@@ -7,44 +7,28 @@ import (
 	"testing"
 	"time"
 	"worker-pool/api"
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	"worker-pool/internal/db/mocks"
 	sqlc "worker-pool/internal/db/sqlc/generated"
 	"worker-pool/internal/services"
 
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-type mockStore struct {
-	createWebhookFn     func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error)
-	createWebhookCalls  int
-	lastCreateWebhookArg sqlc.CreateWebhookParams
-}
-
-func (m *mockStore) ClaimNextWebhook(ctx context.Context) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
-}
-
-func (m *mockStore) CreateWebhook(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
-	m.createWebhookCalls++
-	m.lastCreateWebhookArg = arg
-	if m.createWebhookFn != nil {
-		return m.createWebhookFn(ctx, arg)
-	}
-	return sqlc.WebhookEvent{}, nil
-}
-
-func (m *mockStore) MarkWebhookDone(ctx context.Context, id uuid.UUID) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
-}
-
-func (m *mockStore) MarkWebhookFailed(ctx context.Context, arg sqlc.MarkWebhookFailedParams) (sqlc.WebhookEvent, error) {
-	return sqlc.WebhookEvent{}, nil
+func newTestService(t *testing.T, store db.Store) *services.WebhookService {
+	t.Helper()
+	validator, err := services.NewPaymentValidator(config.DefaultValidationConfig())
+	require.NoError(t, err)
+	return services.NewWebhookService(store, validator)
 }
 
 func TestProcessPaymentWebhook_Success(t *testing.T) {
-	store := &mockStore{}
-	svc := services.NewWebhookService(store)
+	store := dbtest.NewStore()
+	svc := newTestService(t, store)
 	req := api.WebhookPaymentJSONRequestBody{
 		EventId:    "evt_123",
 		Type:       "payment.completed",
@@ -53,16 +37,19 @@ func TestProcessPaymentWebhook_Success(t *testing.T) {
 		OccurredAt: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC),
 	}
 
-	err := svc.ProcessPaymentWebhook(req)
+	err := svc.ProcessPaymentWebhook(context.Background(), req)
 
 	require.NoError(t, err)
-	assert.Equal(t, 1, store.createWebhookCalls)
-	assert.Equal(t, req.EventId, store.lastCreateWebhookArg.EventID)
-	require.NotNil(t, store.lastCreateWebhookArg.Type)
-	assert.Equal(t, req.Type, *store.lastCreateWebhookArg.Type)
+	events := store.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, req.EventId, events[0].EventID)
+	assert.Equal(t, services.DefaultTenant, events[0].TenantID)
+	assert.Equal(t, db.ReceivedStatus, events[0].Status)
+	require.NotNil(t, events[0].Type)
+	assert.Equal(t, req.Type, *events[0].Type)
 
 	var payload map[string]any
-	require.NoError(t, json.Unmarshal(store.lastCreateWebhookArg.Payload, &payload))
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
 	assert.Equal(t, req.EventId, payload["event_id"])
 	assert.Equal(t, req.Type, payload["type"])
 	assert.Equal(t, req.Amount, payload["amount"])
@@ -70,12 +57,11 @@ func TestProcessPaymentWebhook_Success(t *testing.T) {
 }
 
 func TestProcessPaymentWebhook_StoreError(t *testing.T) {
-	store := &mockStore{
-		createWebhookFn: func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
-			return sqlc.WebhookEvent{}, errors.New("db write failed")
-		},
-	}
-	svc := services.NewWebhookService(store)
+	store := mocks.NewStore(t)
+	store.EXPECT().CreateWebhook(mock.Anything, mock.Anything).
+		Return(sqlc.WebhookEvent{}, errors.New("db write failed")).
+		Once()
+	svc := newTestService(t, store)
 	req := api.WebhookPaymentJSONRequestBody{
 		EventId:    "evt_123",
 		Type:       "payment.failed",
@@ -84,9 +70,109 @@ func TestProcessPaymentWebhook_StoreError(t *testing.T) {
 		OccurredAt: time.Now().UTC(),
 	}
 
-	err := svc.ProcessPaymentWebhook(req)
+	err := svc.ProcessPaymentWebhook(context.Background(), req)
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "create webhook")
-	assert.Equal(t, 1, store.createWebhookCalls)
+}
+
+func TestProcessPaymentWebhook_PassesContextToStore(t *testing.T) {
+	type ctxKey struct{}
+	var gotCtx context.Context
+	store := mocks.NewStore(t)
+	store.EXPECT().CreateWebhook(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, arg sqlc.CreateWebhookParams) (sqlc.WebhookEvent, error) {
+			gotCtx = ctx
+			return sqlc.WebhookEvent{}, ctx.Err()
+		})
+	svc := newTestService(t, store)
+	req := api.WebhookPaymentJSONRequestBody{
+		EventId:    "evt_123",
+		Type:       "payment.completed",
+		Amount:     "900",
+		Currency:   "USD",
+		OccurredAt: time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	cancel()
+	err := svc.ProcessPaymentWebhook(ctx, req)
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, gotCtx)
+	assert.Equal(t, "request", gotCtx.Value(ctxKey{}))
+}
+
+func TestProcessPaymentWebhook_ValidationError(t *testing.T) {
+	store := dbtest.NewStore()
+	svc := newTestService(t, store)
+	req := api.WebhookPaymentJSONRequestBody{
+		EventId:    "evt_123",
+		Type:       "payment.completed",
+		Amount:     "-5",
+		Currency:   "NGN",
+		OccurredAt: time.Now().UTC(),
+	}
+
+	err := svc.ProcessPaymentWebhook(context.Background(), req)
+
+	var verr *services.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Empty(t, store.Events())
+}
+
+func TestGetEvent(t *testing.T) {
+	errText := "downstream timeout"
+	store := dbtest.NewStore()
+	event := store.PutEvent(sqlc.WebhookEvent{TenantID: services.DefaultTenant, EventID: "evt_1", Attempts: 1})
+	_, err := store.CreateWebhookAttempt(context.Background(), sqlc.CreateWebhookAttemptParams{
+		WebhookEventID: event.ID,
+		Attempt:        1,
+		Outcome:        db.AttemptRetrying,
+		Error:          &errText,
+	})
+	require.NoError(t, err)
+	store.PutEvent(sqlc.WebhookEvent{TenantID: services.DefaultTenant, EventID: "evt_2"})
+	service := newTestService(t, store)
+
+	details, err := service.GetEvent(context.Background(), "evt_1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "evt_1", details.Event.EventID)
+	assert.Equal(t, event.ID, details.Event.ID)
+	require.Len(t, details.Attempts, 1)
+	assert.Equal(t, &errText, details.Attempts[0].Error)
+}
+
+func TestTenantScoping(t *testing.T) {
+	store := dbtest.NewStore()
+	svc := newTestService(t, store)
+	acme := services.WithPrincipal(context.Background(), services.Principal{TenantID: "acme"})
+	globex := services.WithPrincipal(context.Background(), services.Principal{TenantID: "globex"})
+
+	err := svc.ProcessPaymentWebhook(acme, api.WebhookPaymentJSONRequestBody{
+		EventId:    "evt_123",
+		Type:       "payment.completed",
+		Amount:     "5000",
+		Currency:   "NGN",
+		OccurredAt: time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Len(t, store.Events(), 1)
+	assert.Equal(t, "acme", store.Events()[0].TenantID)
+
+	details, err := svc.GetEvent(acme, "evt_123")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", details.Event.TenantID)
+
+	_, err = svc.GetEvent(globex, "evt_123")
+	assert.ErrorIs(t, err, services.ErrEventNotFound)
+}
+
+func TestGetEvent_NotFound(t *testing.T) {
+	service := newTestService(t, dbtest.NewStore())
+
+	_, err := service.GetEvent(context.Background(), "evt_missing")
+
+	assert.ErrorIs(t, err, services.ErrEventNotFound)
 }
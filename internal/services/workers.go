@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/google/uuid"
+)
+
+// Worker pool process states reported by the admin API.
+const (
+	WorkerLive    = "live"
+	WorkerDead    = "dead"
+	WorkerStopped = "stopped"
+)
+
+// WorkerStatus is a registered worker pool process and the events it
+// currently holds.
+type WorkerStatus struct {
+	Worker     sqlc.Worker
+	State      string
+	Processing []sqlc.WebhookEvent
+}
+
+type WorkerService struct {
+	store            db.Store
+	heartbeatTimeout time.Duration
+	now              func() time.Time
+}
+
+func NewWorkerService(store db.Store, heartbeatTimeout time.Duration) *WorkerService {
+	return &WorkerService{
+		store:            store,
+		heartbeatTimeout: heartbeatTimeout,
+		now:              time.Now,
+	}
+}
+
+// ListWorkers returns every registered process, newest first. A process
+// that never deregistered and has missed its heartbeat timeout is dead; any
+// events it still claims are listed so they can be chased up.
+func (s *WorkerService) ListWorkers(ctx context.Context) ([]WorkerStatus, error) {
+	workers, err := s.store.ListWorkers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list workers: %w", err)
+	}
+
+	processing, err := s.store.ListProcessingWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list processing webhooks: %w", err)
+	}
+
+	statuses := make([]WorkerStatus, 0, len(workers))
+	byID := make(map[uuid.UUID]int, len(workers))
+	for _, w := range workers {
+		byID[w.ID] = len(statuses)
+		statuses = append(statuses, WorkerStatus{
+			Worker:     w,
+			State:      s.state(w),
+			Processing: []sqlc.WebhookEvent{},
+		})
+	}
+
+	for _, event := range processing {
+		if i, ok := byID[uuid.UUID(event.ClaimedBy.Bytes)]; ok {
+			statuses[i].Processing = append(statuses[i].Processing, event)
+		}
+	}
+
+	return statuses, nil
+}
+
+func (s *WorkerService) state(w sqlc.Worker) string {
+	switch {
+	case w.StoppedAt.Valid:
+		return WorkerStopped
+	case s.now().Sub(w.HeartbeatAt.Time) > s.heartbeatTimeout:
+		return WorkerDead
+	default:
+		return WorkerLive
+	}
+}
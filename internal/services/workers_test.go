@@ -0,0 +1,73 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	"worker-pool/internal/db/mocks"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func ts(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func TestListWorkers(t *testing.T) {
+	now := time.Now()
+	live, dead, stopped := uuid.New(), uuid.New(), uuid.New()
+
+	store := dbtest.NewStore()
+	// Workers are listed newest first.
+	store.PutWorker(sqlc.Worker{ID: live, StartedAt: ts(now.Add(-time.Minute)), HeartbeatAt: ts(now.Add(-5 * time.Second))})
+	store.PutWorker(sqlc.Worker{ID: dead, StartedAt: ts(now.Add(-time.Hour)), HeartbeatAt: ts(now.Add(-2 * time.Minute))})
+	store.PutWorker(sqlc.Worker{ID: stopped, StartedAt: ts(now.Add(-2 * time.Hour)), HeartbeatAt: ts(now.Add(-2 * time.Minute)), StoppedAt: pgtype.Timestamp{Time: now, Valid: true}})
+	for i, claim := range []struct {
+		eventID string
+		worker  uuid.UUID
+	}{{"evt_a", live}, {"evt_b", dead}, {"evt_c", live}} {
+		store.PutEvent(sqlc.WebhookEvent{
+			EventID:   claim.eventID,
+			Status:    db.ProcessingStatus,
+			ClaimedBy: pgtype.UUID{Bytes: claim.worker, Valid: true},
+			ClaimedAt: pgtype.Timestamp{Time: now.Add(time.Duration(i-10) * time.Second), Valid: true},
+		})
+	}
+	service := services.NewWorkerService(store, 30*time.Second)
+
+	statuses, err := service.ListWorkers(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, services.WorkerLive, statuses[0].State)
+	require.Len(t, statuses[0].Processing, 2)
+	assert.Equal(t, "evt_a", statuses[0].Processing[0].EventID)
+	assert.Equal(t, "evt_c", statuses[0].Processing[1].EventID)
+
+	assert.Equal(t, services.WorkerDead, statuses[1].State)
+	require.Len(t, statuses[1].Processing, 1)
+	assert.Equal(t, "evt_b", statuses[1].Processing[0].EventID)
+
+	assert.Equal(t, services.WorkerStopped, statuses[2].State)
+	assert.Empty(t, statuses[2].Processing)
+}
+
+func TestListWorkers_StoreError(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().ListWorkers(mock.Anything).Return(nil, errors.New("db down"))
+	service := services.NewWorkerService(store, 30*time.Second)
+
+	_, err := service.ListWorkers(context.Background())
+
+	assert.ErrorContains(t, err, "list workers")
+}
@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errLeaseLost is the cause a handler's context is cancelled with, and the
+// error an attempt returns, when the event was reclaimed before the attempt
+// could settle it.
+var errLeaseLost = errors.New("lease on the webhook was lost")
+
+// attempt runs one try at an event through the middleware chain, settles
+// its status and appends the try to the event's attempt history. It holds
+// the lease on the event until the handler returns or its deadline passes,
+// and returns the attempt's error. If the lease is lost the handler's
+// context is cancelled and the result is discarded: the event belongs to
+// whichever claim reclaimed it.
+func (p *Pool) attempt(ctx context.Context, workerID string, event sqlc.WebhookEvent) error {
+	startedAt := time.Now()
+	deadline := startedAt.Add(p.settings.Load().TimeoutFor(typeKey(event)) + abandonGrace)
+	handlerCtx, cancelHandler := context.WithCancelCause(ctx)
+	defer cancelHandler(nil)
+	stopLease := p.holdLease(ctx, event, deadline, cancelHandler)
+	err := p.handler()(handlerCtx, event)
+	duration := time.Since(startedAt)
+	stopLease()
+
+	if err != nil && ctx.Err() != nil {
+		// Cut short by the pool stopping: the handler did not fail, so the
+		// attempt is not counted against the event.
+		p.releaseInterrupted(ctx, event, err)
+		return err
+	}
+
+	if err == nil {
+		_, err = p.markDone(ctx, event)
+		if errors.Is(err, pgx.ErrNoRows) {
+			p.discardAttempt(event, nil)
+			return errLeaseLost
+		}
+		if err != nil {
+			err = fmt.Errorf("mark done: %w", err)
+		}
+	}
+
+	outcome := db.AttemptSucceeded
+	if err != nil {
+		p.log.Warn().Err(err).Str("event_id", event.EventID).Int32("attempt", event.Attempts).Msg("Processing failed")
+		var settled bool
+		if outcome, settled = p.recordFailure(ctx, event, err); !settled {
+			p.discardAttempt(event, err)
+			return errors.Join(err, errLeaseLost)
+		}
+	} else {
+		p.log.Info().Str("event_id", event.EventID).Msg("Webhook marked done")
+	}
+
+	metrics.WebhooksProcessed.WithLabelValues(event.TenantID, outcome).Inc()
+	p.recordAttempt(ctx, sqlc.CreateWebhookAttemptParams{
+		WebhookEventID: event.ID,
+		Attempt:        event.Attempts,
+		WorkerID:       workerID,
+		Host:           p.host,
+		StartedAt:      pgtype.Timestamptz{Time: startedAt, Valid: true},
+		FinishedAt:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Outcome:        outcome,
+		Reason:         failureReason(err),
+		Error:          errorText(err),
+		DurationMs:     duration.Milliseconds(),
+	})
+
+	if p.hooks.OnAttempt != nil {
+		p.hooks.OnAttempt(ctx, Attempt{
+			Event:    event,
+			WorkerID: workerID,
+			Outcome:  outcome,
+			Err:      err,
+			Duration: duration,
+		})
+	}
+	return err
+}
+
+// markDone settles a successful attempt. Like recordFailure it runs on a
+// detached context so a shutdown right after the handler returns does not
+// turn the success into a failure.
+func (p *Pool) markDone(ctx context.Context, event sqlc.WebhookEvent) (sqlc.WebhookEvent, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failureWriteTimeout)
+	defer cancel()
+	return p.store.MarkWebhookDone(ctx, sqlc.MarkWebhookDoneParams{ID: event.ID, ClaimedBy: p.id})
+}
+
+// releaseInterrupted hands back an event whose handler was cancelled by the
+// pool stopping, without recording an attempt. An abandoned handler may
+// still be running, so its event is left claimed for the reclaim path
+// instead.
+func (p *Pool) releaseInterrupted(ctx context.Context, event sqlc.WebhookEvent, cause error) {
+	if abandoned(cause) != nil {
+		p.log.Warn().Str("event_id", event.EventID).Msg("Shutdown interrupted a stuck handler; webhook left claimed until its lease expires")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failureWriteTimeout)
+	defer cancel()
+
+	_, err := p.store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: event.ID, ClaimedBy: p.id})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		p.discardAttempt(event, cause)
+	case err != nil:
+		// The lease runs out and the event is reclaimed anyway.
+		p.log.Error().Err(err).Str("event_id", event.EventID).Msg("Failed to release interrupted webhook")
+	default:
+		p.log.Info().Str("event_id", event.EventID).Msg("Released webhook interrupted by shutdown")
+	}
+}
+
+// discardAttempt logs an attempt whose event was reclaimed before it was
+// settled. It is neither counted nor recorded, since the reclaiming claim
+// owns the event's outcome.
+func (p *Pool) discardAttempt(event sqlc.WebhookEvent, cause error) {
+	p.log.Warn().
+		Err(cause).
+		Str("event_id", event.EventID).
+		Int32("attempt", event.Attempts).
+		Msg("Lost the lease before settling the webhook; discarding the attempt")
+}
+
+// recordFailure reschedules the event with exponential backoff, or marks it
+// failed once it has used all of its attempts. It returns the attempt
+// outcome, and false if the lease was lost so the event was not settled.
+func (p *Pool) recordFailure(ctx context.Context, event sqlc.WebhookEvent, cause error) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failureWriteTimeout)
+	defer cancel()
+
+	errStr := cause.Error()
+
+	if event.Attempts < p.retry.MaxAttempts && abandoned(cause) != nil {
+		// The abandoned handler may still be running, so rescheduling now
+		// could run the event twice at once. Leave it claimed; it goes back
+		// to the queue when its lease expires.
+		p.log.Warn().Str("event_id", event.EventID).Msg("Webhook left claimed until its lease expires")
+		return db.AttemptRetrying, true
+	}
+
+	if event.Attempts < p.retry.MaxAttempts {
+		nextAttempt := time.Now().Add(retryBackoff(p.retry, event.Attempts))
+		_, err := p.store.RetryWebhook(ctx, sqlc.RetryWebhookParams{
+			ID:            event.ID,
+			ClaimedBy:     p.id,
+			LastError:     &errStr,
+			NextAttemptAt: pgtype.Timestamptz{Time: nextAttempt, Valid: true},
+		})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return "", false
+		case err != nil:
+			p.log.Error().Err(err).Str("event_id", event.EventID).Msg("Failed to reschedule webhook")
+		default:
+			p.log.Info().Str("event_id", event.EventID).Time("next_attempt_at", nextAttempt).Msg("Webhook scheduled for retry")
+		}
+		return db.AttemptRetrying, true
+	}
+
+	_, err := p.store.MarkWebhookFailed(ctx, sqlc.MarkWebhookFailedParams{ID: event.ID, ClaimedBy: p.id, LastError: &errStr})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return "", false
+	case err != nil:
+		p.log.Error().Err(err).Str("event_id", event.EventID).Msg("Failed to mark webhook failed")
+	default:
+		p.log.Warn().Str("event_id", event.EventID).Int32("attempts", event.Attempts).Msg("Webhook marked failed")
+	}
+	return db.AttemptFailed, true
+}
+
+// recordAttempt appends to the attempt history. Like recordFailure it runs
+// on a detached context so a shutdown mid-attempt still leaves a record.
+func (p *Pool) recordAttempt(ctx context.Context, arg sqlc.CreateWebhookAttemptParams) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), failureWriteTimeout)
+	defer cancel()
+
+	if _, err := p.store.CreateWebhookAttempt(ctx, arg); err != nil {
+		p.log.Error().Err(err).Str("worker", arg.WorkerID).Int32("attempt", arg.Attempt).Msg("Failed to record webhook attempt")
+	}
+}
+
+// failureReason classifies a failed attempt for the attempt history.
+func failureReason(err error) *string {
+	if err == nil {
+		return nil
+	}
+	reason := db.AttemptReasonError
+	var panicErr *panicError
+	var timeoutErr *timeoutError
+	switch {
+	case errors.As(err, &panicErr):
+		reason = db.AttemptReasonPanic
+	case errors.As(err, &timeoutErr):
+		reason = db.AttemptReasonTimeout
+	}
+	return &reason
+}
+
+// errorText is the attempt history's error, with the stack trace appended
+// for a recovered panic.
+func errorText(err error) *string {
+	if err == nil {
+		return nil
+	}
+	s := err.Error()
+	var panicErr *panicError
+	if errors.As(err, &panicErr) {
+		s += "\n\n" + string(panicErr.stack)
+	}
+	return &s
+}
+
+func retryBackoff(retryCfg config.RetryConfig, attempt int32) time.Duration {
+	backoff := retryCfg.InitialBackoff
+	for i := int32(1); i < attempt && backoff < retryCfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, retryCfg.MaxBackoff)
+}
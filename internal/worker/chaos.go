@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"worker-pool/internal/config"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+)
+
+// Faults chaos mode injects. They double as the markers an event ID can
+// carry: evt_chaos-<fault>-...
+const (
+	faultError   = "error"
+	faultPanic   = "panic"
+	faultHang    = "hang"
+	faultLatency = "latency"
+)
+
+const chaosMarkerPrefix = "evt_chaos-"
+
+// errChaos is the failure returned for an injected error.
+var errChaos = errors.New("chaos: injected failure")
+
+// chaos injects faults before the handler as the current chaos settings say.
+func (p *Pool) chaos() Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			if err := p.injectChaos(ctx, event, p.settings.Load().Chaos); err != nil {
+				return err
+			}
+			return next(ctx, event)
+		}
+	}
+}
+
+// injectChaos runs before the handler. It delays, fails, panics or hangs
+// until ctx is done as the event's marker or its type's rule says, and does
+// nothing when chaos is off.
+func (p *Pool) injectChaos(ctx context.Context, event sqlc.WebhookEvent, cfg config.ChaosConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	rule := cfg.Rule(typeKey(event))
+
+	if cfg.Markers {
+		if fault := markedFault(event.EventID); fault != "" {
+			return p.injectFault(ctx, event, rule, fault)
+		}
+	}
+	if rule.LatencyRate > 0 && rand.Float64() < rule.LatencyRate {
+		if err := p.injectFault(ctx, event, rule, faultLatency); err != nil {
+			return err
+		}
+	}
+	if fault := rollFault(rule); fault != "" {
+		return p.injectFault(ctx, event, rule, fault)
+	}
+	return nil
+}
+
+func (p *Pool) injectFault(ctx context.Context, event sqlc.WebhookEvent, rule config.ChaosRule, fault string) error {
+	eventType := typeKey(event)
+	metrics.ChaosFaults.WithLabelValues(eventType, fault).Inc()
+	logger := p.log.Warn().Str("event_id", event.EventID).Str("type", eventType).Str("chaos", fault)
+
+	switch fault {
+	case faultLatency:
+		delay := chaosLatency(rule)
+		logger.Dur("delay", delay).Msg("Chaos: delaying event")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		return nil
+	case faultHang:
+		logger.Msg("Chaos: hanging until the handler times out")
+		<-ctx.Done()
+		return ctx.Err()
+	case faultPanic:
+		logger.Msg("Chaos: panicking")
+		panic("chaos: injected panic for event " + event.EventID)
+	default:
+		logger.Msg("Chaos: failing event")
+		return errChaos
+	}
+}
+
+// markedFault is the fault an event ID asks for, or "" when it has no
+// recognised marker.
+func markedFault(eventID string) string {
+	rest, ok := strings.CutPrefix(eventID, chaosMarkerPrefix)
+	if !ok {
+		return ""
+	}
+	fault, _, _ := strings.Cut(rest, "-")
+	switch fault {
+	case faultError, faultPanic, faultHang, faultLatency:
+		return fault
+	}
+	return ""
+}
+
+// rollFault picks at most one of hang, panic and error with a single roll.
+func rollFault(rule config.ChaosRule) string {
+	roll := rand.Float64()
+	switch {
+	case roll < rule.HangRate:
+		return faultHang
+	case roll < rule.HangRate+rule.PanicRate:
+		return faultPanic
+	case roll < rule.HangRate+rule.PanicRate+rule.ErrorRate:
+		return faultError
+	}
+	return ""
+}
+
+// chaosLatency draws a delay from the rule's latency distribution.
+func chaosLatency(rule config.ChaosRule) time.Duration {
+	if rule.LatencyDistribution == config.ChaosLatencyExponential {
+		delay := rule.LatencyMin + time.Duration(rand.ExpFloat64()*float64(rule.LatencyMean))
+		if rule.LatencyMax > 0 {
+			delay = min(delay, rule.LatencyMax)
+		}
+		return delay
+	}
+	return rule.LatencyMin + time.Duration(rand.Int64N(int64(rule.LatencyMax-rule.LatencyMin)+1))
+}
+
+// logChaos warns that chaos mode is on, with its rules, so it is never left
+// on by accident unnoticed.
+func (p *Pool) logChaos(cfg config.ChaosConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	p.log.Warn().
+		Bool("markers", cfg.Markers).
+		Interface("default", cfg.Default).
+		Interface("types", cfg.Types).
+		Msg("CHAOS MODE ENABLED: faults are being injected into event processing. Never run this in production")
+}
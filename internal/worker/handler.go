@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+)
+
+// EventHandler processes one claimed event. Returning nil marks the event
+// done; an error fails the attempt, which is retried with backoff until the
+// event runs out of attempts. ctx is cancelled when the handler's timeout
+// passes or the pool stops.
+type EventHandler func(ctx context.Context, event sqlc.WebhookEvent) error
+
+// ErrNoHandler is the attempt failure for an event whose type has no
+// handler and no default is registered.
+var ErrNoHandler = errors.New("no handler for event type")
+
+// Registry maps event types to their handlers. It is safe for concurrent
+// use, so handlers can be registered while the pool is running.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]EventHandler
+	fallback EventHandler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]EventHandler)}
+}
+
+// Handle registers the handler for an event type, replacing any previous
+// one. The empty type matches events that were ingested without one.
+func (r *Registry) Handle(eventType string, h EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = h
+}
+
+// HandleDefault registers the handler for event types with none of their own.
+func (r *Registry) HandleDefault(h EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = h
+}
+
+// Lookup returns the handler for an event type, falling back to the default.
+func (r *Registry) Lookup(eventType string) (EventHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if h, ok := r.handlers[eventType]; ok {
+		return h, true
+	}
+	return r.fallback, r.fallback != nil
+}
+
+// dispatch runs the event's handler.
+func (r *Registry) dispatch(ctx context.Context, event sqlc.WebhookEvent) error {
+	h, ok := r.Lookup(typeKey(event))
+	if !ok {
+		return fmt.Errorf("%w %q", ErrNoHandler, typeKey(event))
+	}
+	return h(ctx, event)
+}
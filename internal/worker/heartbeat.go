@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The pool keeps its row in the workers table fresh so the admin API can
+// tell live processes from dead ones, and marks it stopped on exit. Each
+// heartbeat also reclaims events whose leases have expired.
+
+// register inserts the pool's row. It must succeed before any worker
+// starts, since claimed events reference it.
+func (p *Pool) register(ctx context.Context) error {
+	_, err := p.store.RegisterWorker(ctx, sqlc.RegisterWorkerParams{
+		ID:          p.id,
+		Host:        p.host,
+		Pid:         int32(os.Getpid()),
+		Version:     p.version,
+		Concurrency: int32(p.settings.Load().PoolSize),
+	})
+	return err
+}
+
+// heartbeat refreshes the pool's row and reclaims expired events until ctx
+// is cancelled.
+func (p *Pool) heartbeat(ctx context.Context) {
+	defer close(p.heartbeatDone)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.settings.Load().HeartbeatInterval):
+		}
+
+		_, err := p.store.HeartbeatWorker(ctx, sqlc.HeartbeatWorkerParams{
+			ID:          p.id,
+			Concurrency: int32(p.settings.Load().PoolSize),
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Someone pruned our row; put it back rather than run unlisted.
+			err = p.register(ctx)
+		}
+		if err != nil && ctx.Err() == nil {
+			p.log.Warn().Err(err).Msg("Failed to send worker heartbeat")
+		}
+
+		p.reclaim(ctx)
+	}
+}
+
+func (p *Pool) markStopped() {
+	ctx, cancel := context.WithTimeout(context.Background(), failureWriteTimeout)
+	defer cancel()
+
+	if _, err := p.store.StopWorker(ctx, p.id); err != nil {
+		p.log.Warn().Err(err).Msg("Failed to mark worker pool stopped")
+	}
+}
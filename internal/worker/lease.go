@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// A claim is a lease on the event: claimed_at is renewed every
+// HeartbeatInterval while the attempt runs, and an event whose lease is
+// older than HeartbeatTimeout, or whose pool has stopped or gone quiet, is
+// returned to the queue by any pool's heartbeat. Renewal stops at the
+// attempt's deadline, so a handler that ignores it and is abandoned keeps
+// the event out of the queue only until the lease runs out. Settling is
+// fenced on the claim, so once the lease is lost nothing the old attempt
+// does can overwrite the reclaimed event.
+
+// holdLease renews the lease on event until the returned stop is called or
+// deadline passes, whichever is first. If the lease turns out to be lost,
+// lost is called with errLeaseLost so the handler stops early.
+func (p *Pool) holdLease(ctx context.Context, event sqlc.WebhookEvent, deadline time.Time, lost context.CancelCauseFunc) (stop func()) {
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.settings.Load().HeartbeatInterval):
+			}
+
+			_, err := p.store.RenewWebhookLease(ctx, sqlc.RenewWebhookLeaseParams{ID: event.ID, ClaimedBy: p.id})
+			switch {
+			case errors.Is(err, pgx.ErrNoRows):
+				p.log.Warn().Str("event_id", event.EventID).Msg("Lost the lease on a webhook being processed")
+				lost(errLeaseLost)
+				return
+			case err != nil && ctx.Err() == nil:
+				p.log.Warn().Err(err).Str("event_id", event.EventID).Msg("Failed to renew webhook lease")
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// reclaim returns events with expired leases to the queue.
+func (p *Pool) reclaim(ctx context.Context) {
+	events, err := p.store.ReclaimExpiredWebhooks(ctx, p.settings.Load().HeartbeatTimeout.Seconds())
+	if err != nil {
+		if ctx.Err() == nil {
+			p.log.Warn().Err(err).Msg("Failed to reclaim expired webhooks")
+		}
+		return
+	}
+	for _, event := range events {
+		metrics.WebhooksReclaimed.WithLabelValues(event.TenantID).Inc()
+		p.log.Warn().Str("event_id", event.EventID).Str("tenant", event.TenantID).Msg("Reclaimed webhook with an expired lease")
+	}
+}
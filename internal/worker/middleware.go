@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+	"worker-pool/internal/services"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware wraps an EventHandler the way Echo middleware wraps an HTTP
+// handler: it can act before and after next, replace its context, or not
+// call it at all.
+type Middleware func(next EventHandler) EventHandler
+
+// Chain wraps h in mws, the first outermost.
+func Chain(h EventHandler, mws ...Middleware) EventHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+const tracerName = "worker-pool/internal/worker"
+
+// Tracing runs each attempt in a consumer span carrying the event's ID,
+// tenant, type and attempt number, and marks the span failed when the
+// handler returns an error. A nil tracer uses the global provider's.
+func Tracing(tracer trace.Tracer) Middleware {
+	if tracer == nil {
+		tracer = otel.Tracer(tracerName)
+	}
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			ctx, span := tracer.Start(ctx, "webhook.process",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("webhook.id", event.ID.String()),
+					attribute.String("webhook.event_id", event.EventID),
+					attribute.String("webhook.tenant", event.TenantID),
+					attribute.String("webhook.type", typeKey(event)),
+					attribute.Int("webhook.attempt", int(event.Attempts)),
+				))
+			defer span.End()
+
+			err := next(ctx, event)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// Logging puts a logger carrying the event's fields, and the trace and span
+// IDs when there is a span, in the handler's context, where handlers and
+// the middleware inside it get it with zerolog.Ctx.
+func Logging(logger zerolog.Logger) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			fields := logger.With().
+				Str("event_id", event.EventID).
+				Str("tenant", event.TenantID).
+				Str("type", typeKey(event)).
+				Int32("attempt", event.Attempts)
+			if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+				fields = fields.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+			}
+			l := fields.Logger()
+			ctx = l.WithContext(ctx)
+
+			l.Debug().Msg("Handling webhook")
+			startedAt := time.Now()
+			err := next(ctx, event)
+			l.Debug().Err(err).Dur("duration", time.Since(startedAt)).Msg("Handler returned")
+			return err
+		}
+	}
+}
+
+// Metrics times the handler by event type and result, and counts recovered
+// panics and timeouts. It must wrap Timeout and Recover to see them.
+func Metrics() Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			startedAt := time.Now()
+			err := next(ctx, event)
+
+			result := "ok"
+			var panicErr *panicError
+			var timeoutErr *timeoutError
+			switch {
+			case errors.As(err, &panicErr):
+				result = "panic"
+				metrics.WebhookPanics.WithLabelValues(event.TenantID, typeKey(event)).Inc()
+			case errors.As(err, &timeoutErr):
+				result = "timeout"
+				metrics.WebhookTimeouts.WithLabelValues(event.TenantID, typeKey(event)).Inc()
+			case err != nil:
+				result = "error"
+			}
+			metrics.WebhookHandlerDuration.WithLabelValues(typeKey(event), result).Observe(time.Since(startedAt).Seconds())
+			return err
+		}
+	}
+}
+
+// timeoutError is an attempt that ran past its handler timeout. When the
+// handler ignored its deadline and was abandoned, returned is closed once it
+// finally returns; it is nil otherwise.
+type timeoutError struct {
+	timeout  time.Duration
+	returned <-chan struct{}
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("handler timed out after %s", e.timeout)
+}
+
+// abandoned is closed once the handler behind err returns, when err is an
+// abandoned handler's timeout. It is nil for any other error.
+func abandoned(err error) <-chan struct{} {
+	var timeoutErr *timeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.returned
+	}
+	return nil
+}
+
+// Timeout runs the handler under the timeout timeoutFor gives the event. A
+// handler that ignores its cancelled context is abandoned after
+// abandonGrace: the attempt fails with a *timeoutError and the worker moves
+// on rather than being held by a stuck handler. The pool leaves an abandoned
+// event claimed until its lease runs out, so it is not run again while the
+// handler may still be going. The handler runs on its own goroutine, so
+// Recover must sit inside Timeout.
+func Timeout(timeoutFor func(event sqlc.WebhookEvent) time.Duration) Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			timeout := timeoutFor(event)
+			timedOut := &timeoutError{timeout: timeout}
+			handlerCtx, cancel := context.WithTimeoutCause(ctx, timeout, timedOut)
+			defer cancel()
+
+			done := make(chan error, 1)
+			returned := make(chan struct{})
+			go func() {
+				defer close(returned)
+				done <- next(handlerCtx, event)
+			}()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-handlerCtx.Done():
+				select {
+				case err = <-done:
+				case <-time.After(abandonGrace):
+					zerolog.Ctx(ctx).Error().
+						Str("event_id", event.EventID).
+						Dur("timeout", timeout).
+						Msg("Handler ignored its deadline; abandoning it")
+					timedOut.returned = returned
+					err = handlerCtx.Err()
+				}
+			}
+
+			// Report the deadline rather than whatever the handler made of
+			// it, but not when the worker itself is stopping.
+			if err != nil && ctx.Err() == nil && errors.Is(context.Cause(handlerCtx), timedOut) {
+				return timedOut
+			}
+			return err
+		}
+	}
+}
+
+// panicError is a panic recovered from the handler. Its message is short
+// enough for the event's last_error; the stack goes in the attempt history.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// Recover turns a panic in the handler into a *panicError, so the attempt
+// is settled like any other failure and the worker (and the rest of the
+// process) keeps going.
+func Recover() Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					panicErr := &panicError{value: v, stack: debug.Stack()}
+					zerolog.Ctx(ctx).Error().
+						Str("panic", fmt.Sprint(v)).
+						Str("stack", string(panicErr.stack)).
+						Msg("Recovered from panic while processing webhook")
+					err = panicErr
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
+
+// Tenant scopes the handler's context to the event's tenant, so services
+// called from it see services.TenantFrom as they would for an API request
+// made with that tenant's key.
+func Tenant() Middleware {
+	return func(next EventHandler) EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			return next(services.WithPrincipal(ctx, services.Principal{TenantID: event.TenantID}), event)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package worker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/metrics"
+	"worker-pool/internal/services"
+	"worker-pool/internal/worker"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func testEvent(eventType string) sqlc.WebhookEvent {
+	return sqlc.WebhookEvent{
+		ID:       uuid.New(),
+		TenantID: "acme",
+		EventID:  "evt_1",
+		Type:     &eventType,
+		Attempts: 2,
+	}
+}
+
+func TestChain_FirstMiddlewareIsOutermost(t *testing.T) {
+	var order []string
+	trace := func(name string) worker.Middleware {
+		return func(next worker.EventHandler) worker.EventHandler {
+			return func(ctx context.Context, event sqlc.WebhookEvent) error {
+				order = append(order, name+" in")
+				err := next(ctx, event)
+				order = append(order, name+" out")
+				return err
+			}
+		}
+	}
+	h := worker.Chain(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		order = append(order, "handler")
+		return nil
+	}, trace("a"), trace("b"))
+
+	require.NoError(t, h(context.Background(), testEvent("payment.completed")))
+	assert.Equal(t, []string{"a in", "b in", "handler", "b out", "a out"}, order)
+}
+
+func TestTracing_RecordsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	var buf bytes.Buffer
+
+	h := worker.Chain(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		zerolog.Ctx(ctx).Info().Msg("handling")
+		return errors.New("boom")
+	}, worker.Tracing(tracer), worker.Logging(zerolog.New(&buf)))
+	event := testEvent("payment.completed")
+
+	assert.EqualError(t, h(context.Background(), event), "boom")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "webhook.process", span.Name())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.String("webhook.event_id", "evt_1"))
+	assert.Contains(t, span.Attributes(), attribute.String("webhook.tenant", "acme"))
+	assert.Contains(t, span.Attributes(), attribute.Int("webhook.attempt", 2))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &line))
+	assert.Equal(t, span.SpanContext().TraceID().String(), line["trace_id"])
+}
+
+func TestLogging_PutsEventFieldsOnContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := worker.Logging(zerolog.New(&buf).Level(zerolog.InfoLevel))(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		zerolog.Ctx(ctx).Info().Msg("handling")
+		return nil
+	})
+
+	require.NoError(t, h(context.Background(), testEvent("payment.completed")))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "handling", line["message"])
+	assert.Equal(t, "evt_1", line["event_id"])
+	assert.Equal(t, "acme", line["tenant"])
+	assert.Equal(t, "payment.completed", line["type"])
+	assert.EqualValues(t, 2, line["attempt"])
+	assert.NotContains(t, line, "trace_id")
+}
+
+func handlerSamples(t *testing.T, eventType, result string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, metrics.WebhookHandlerDuration.WithLabelValues(eventType, result).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics_ClassifiesFailures(t *testing.T) {
+	event := testEvent("metrics.test")
+	panics := metrics.WebhookPanics.WithLabelValues("acme", "metrics.test")
+	timeouts := metrics.WebhookTimeouts.WithLabelValues("acme", "metrics.test")
+
+	h := worker.Chain(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		panic("boom")
+	}, worker.Metrics(), worker.Recover())
+	assert.Error(t, h(context.Background(), event))
+	assert.Equal(t, 1.0, testutil.ToFloat64(panics))
+
+	h = worker.Chain(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, worker.Metrics(), worker.Timeout(func(sqlc.WebhookEvent) time.Duration { return time.Millisecond }))
+	assert.Error(t, h(context.Background(), event))
+	assert.Equal(t, 1.0, testutil.ToFloat64(timeouts))
+
+	assert.EqualValues(t, 1, handlerSamples(t, "metrics.test", "panic"))
+	assert.EqualValues(t, 1, handlerSamples(t, "metrics.test", "timeout"))
+	assert.EqualValues(t, 0, handlerSamples(t, "metrics.test", "ok"))
+}
+
+func TestTimeout(t *testing.T) {
+	wait := func(ctx context.Context, event sqlc.WebhookEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	timeout := worker.Timeout(func(event sqlc.WebhookEvent) time.Duration {
+		if *event.Type == "slow" {
+			return time.Hour
+		}
+		return 10 * time.Millisecond
+	})
+
+	err := timeout(wait)(context.Background(), testEvent("fast"))
+	assert.EqualError(t, err, "handler timed out after 10ms")
+
+	// A worker stopping mid-attempt is not the handler's timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = timeout(wait)(ctx, testEvent("slow"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotContains(t, err.Error(), "timed out after")
+}
+
+func TestRecover(t *testing.T) {
+	h := worker.Recover()(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		panic("boom")
+	})
+
+	assert.EqualError(t, h(context.Background(), testEvent("payment.completed")), "panic: boom")
+}
+
+func TestTenant(t *testing.T) {
+	var tenant string
+	h := worker.Tenant()(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		tenant = services.TenantFrom(ctx)
+		return nil
+	})
+
+	require.NoError(t, h(context.Background(), testEvent("payment.completed")))
+	assert.Equal(t, "acme", tenant)
+}
+
+func TestPool_CustomMiddleware(t *testing.T) {
+	store := dbtest.NewStore()
+	createEvent(t, store, "evt_1", "payment.completed")
+	createEvent(t, store, "evt_2", "payment.refunded")
+
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	record := func(name string) worker.Middleware {
+		return func(next worker.EventHandler) worker.EventHandler {
+			return func(ctx context.Context, event sqlc.WebhookEvent) error {
+				mu.Lock()
+				seen = append(seen, name+" "+event.EventID+" "+services.TenantFrom(ctx))
+				mu.Unlock()
+				return next(ctx, event)
+			}
+		}
+	}
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error { return nil })
+
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	opts.Middleware = []worker.Middleware{record("option")}
+	p, err := worker.New(opts)
+	require.NoError(t, err)
+	p.Use(record("use"))
+	require.NoError(t, p.Start(context.Background()))
+	t.Cleanup(func() { _ = p.Stop() })
+
+	waitForStatus(t, store, db.DoneStatus)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"option evt_1 acme", "use evt_1 acme",
+		"option evt_2 acme", "use evt_2 acme",
+	}, seen)
+}
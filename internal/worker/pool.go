@@ -0,0 +1,647 @@
+// Package worker is the webhook worker pool: a resizable set of goroutines
+// that claim events from the queue, run the handler registered for their
+// type and settle the outcome with retries. cmd/worker-pool wraps it with
+// config loading, reload and the operational HTTP listener; other services
+// can embed it the same way.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/scheduler"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// failureWriteTimeout bounds the bookkeeping done after a failed attempt,
+// which must still run when the worker context is already cancelled.
+const failureWriteTimeout = 5 * time.Second
+
+// abandonGrace is how long a handler gets to return after its timeout
+// before the worker stops waiting for it and moves on.
+const abandonGrace = time.Second
+
+// Options configure a Pool. Store and Handlers are required.
+type Options struct {
+	Store    db.Store
+	Handlers *Registry
+	// Settings are the pool's concurrency (PoolSize, TypeLimits), polling
+	// and claim strategy, timeouts and chaos rules. They are used as given,
+	// so start from config.Default().Worker rather than the zero value.
+	Settings config.WorkerConfig
+	Retry    config.RetryConfig
+	// Scheduler, when set, replaces the one Settings.ClaimStrategy names.
+	// It picks among partitions of Settings.FairBy.
+	Scheduler scheduler.Scheduler
+	Hooks     Hooks
+	// Middleware wraps every handler, inside the built-in tracing, logging,
+	// metrics, timeout, panic recovery and tenant middleware, in order.
+	// More can be added with Pool.Use.
+	Middleware []Middleware
+	// Logger defaults to the global zerolog logger.
+	Logger *zerolog.Logger
+	// Tracer defaults to the global OpenTelemetry provider's.
+	Tracer trace.Tracer
+	// Host and Version are recorded in the pool's row in the workers table.
+	// Host defaults to the machine's hostname.
+	Host    string
+	Version string
+}
+
+// Hooks are callbacks for the pool's lifecycle. Any of them may be nil.
+// They run on the pool's goroutines, so they must not block.
+type Hooks struct {
+	// OnStart runs once the pool is registered and its workers are running.
+	OnStart func()
+	// OnDrain runs when Drain is called, before in-flight events finish.
+	OnDrain func()
+	// OnStop runs once every worker has exited, with the error that stopped
+	// the pool, or nil when it was stopped or drained.
+	OnStop func(err error)
+	// OnClaim runs after a worker claims an event, before its handler.
+	OnClaim func(ctx context.Context, event sqlc.WebhookEvent)
+	// OnAttempt runs after each attempt is settled and recorded.
+	OnAttempt func(ctx context.Context, attempt Attempt)
+}
+
+// Attempt is one settled try at an event, as passed to Hooks.OnAttempt.
+type Attempt struct {
+	Event    sqlc.WebhookEvent
+	WorkerID string
+	// Outcome is db.AttemptSucceeded, db.AttemptRetrying or db.AttemptFailed.
+	Outcome  string
+	Err      error
+	Duration time.Duration
+}
+
+// Pool runs a resizable set of workers. Settings can be swapped while it is
+// running; workers pick them up on their next claim.
+type Pool struct {
+	store    db.Store
+	handlers *Registry
+	retry    config.RetryConfig
+	settings atomic.Pointer[config.WorkerConfig]
+	hooks    Hooks
+	log      zerolog.Logger
+	tracer   trace.Tracer
+	id       uuid.UUID
+	host     string
+	version  string
+	// scheduler picks the partition of fairBy each claim is taken from; nil
+	// claims in plain FIFO order.
+	scheduler scheduler.Scheduler
+	fairBy    string
+	// backlog caches the partitions with claimable work for a poll interval,
+	// so fair claims don't list them on every claim.
+	backlog backlogCache
+
+	started atomic.Bool
+
+	mu         sync.Mutex
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	stopped    bool
+	draining   bool
+	middleware []Middleware
+	drain      chan struct{}
+	workers    []*workerHandle
+	nextID     int
+	inFlight   map[string]int
+	wg         sync.WaitGroup
+
+	heartbeatDone chan struct{}
+	done          chan struct{}
+	err           error
+
+	// lastLoop is when any worker last went round its claim loop, in Unix
+	// nanoseconds. Readiness fails once it goes stale while no worker is
+	// busy.
+	lastLoop atomic.Int64
+	// busy counts the workers in the middle of an attempt. A busy worker is
+	// alive: its attempt ends by its handler timeout at the latest.
+	busy atomic.Int64
+}
+
+type workerHandle struct {
+	id   int
+	stop chan struct{}
+}
+
+// New builds a pool. It does not touch the database until Start.
+func New(opts Options) (*Pool, error) {
+	if opts.Store == nil {
+		return nil, errors.New("worker pool: a store is required")
+	}
+	if opts.Handlers == nil {
+		return nil, errors.New("worker pool: a handler registry is required")
+	}
+
+	sched := opts.Scheduler
+	if sched == nil {
+		var err error
+		sched, err = scheduler.New(opts.Settings.ClaimStrategy, opts.Settings.FairWeights)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	host := opts.Host
+	if host == "" {
+		var err error
+		if host, err = os.Hostname(); err != nil {
+			host = "unknown"
+		}
+	}
+
+	logger := log.Logger
+	if opts.Logger != nil {
+		logger = *opts.Logger
+	}
+
+	p := &Pool{
+		store:         opts.Store,
+		handlers:      opts.Handlers,
+		retry:         opts.Retry,
+		hooks:         opts.Hooks,
+		log:           logger,
+		tracer:        opts.Tracer,
+		middleware:    slices.Clone(opts.Middleware),
+		id:            uuid.New(),
+		host:          host,
+		version:       opts.Version,
+		scheduler:     sched,
+		fairBy:        opts.Settings.FairBy,
+		drain:         make(chan struct{}),
+		inFlight:      make(map[string]int),
+		heartbeatDone: make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	settings := opts.Settings
+	p.settings.Store(&settings)
+	return p, nil
+}
+
+// ID is the pool's row in the workers table; claimed events reference it.
+func (p *Pool) ID() uuid.UUID {
+	return p.id
+}
+
+// Settings returns the worker settings in effect.
+func (p *Pool) Settings() config.WorkerConfig {
+	return *p.settings.Load()
+}
+
+// Use adds middleware inside any already registered. It can be called
+// while the pool is running; attempts started afterwards go through it.
+func (p *Pool) Use(mws ...Middleware) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.middleware = append(p.middleware, mws...)
+}
+
+// handler is the registry wrapped in the middleware chain. From the
+// outside in: the span comes first so the logger can carry its IDs,
+// Metrics wraps Timeout and Recover to classify their failures, Recover
+// runs on Timeout's handler goroutine, and chaos is injected last, as if
+// by the handler itself.
+func (p *Pool) handler() EventHandler {
+	p.mu.Lock()
+	custom := slices.Clone(p.middleware)
+	p.mu.Unlock()
+
+	mws := []Middleware{
+		Tracing(p.tracer),
+		Logging(p.log),
+		Metrics(),
+		Timeout(func(event sqlc.WebhookEvent) time.Duration {
+			return p.settings.Load().TimeoutFor(typeKey(event))
+		}),
+		Recover(),
+		Tenant(),
+	}
+	mws = append(mws, custom...)
+	mws = append(mws, p.chaos())
+	return Chain(p.handlers.dispatch, mws...)
+}
+
+// workerID identifies one worker goroutine across every pool process
+// sharing the database: the process's registry ID plus the worker's slot.
+func (p *Pool) workerID(w *workerHandle) string {
+	return fmt.Sprintf("%s-%d", p.id, w.id)
+}
+
+// Start registers the pool in the workers table and starts its workers and
+// heartbeat. ctx only bounds the registration: the pool runs until Stop or
+// Drain is called or a worker hits an unrecoverable error, after which Done
+// is closed and Err reports why. A pool can only be started once.
+func (p *Pool) Start(ctx context.Context) error {
+	if !p.started.CompareAndSwap(false, true) {
+		return errors.New("worker pool already started")
+	}
+	if err := p.register(ctx); err != nil {
+		p.started.Store(false)
+		return fmt.Errorf("register worker pool: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancelCause(context.WithoutCancel(ctx))
+	p.mu.Lock()
+	p.ctx, p.cancel = runCtx, cancel
+	p.mu.Unlock()
+	p.lastLoop.Store(time.Now().UnixNano())
+
+	settings := p.settings.Load()
+	p.log.Info().Str("worker_id", p.id.String()).Str("version", p.version).Msg("Registered worker pool")
+	p.logChaos(settings.Chaos)
+
+	go p.heartbeat(runCtx)
+	p.resize(settings.PoolSize)
+	go func() {
+		<-runCtx.Done()
+		p.wg.Wait()
+		p.finish()
+	}()
+
+	if p.hooks.OnStart != nil {
+		p.hooks.OnStart()
+	}
+	return nil
+}
+
+// Stop cancels every in-flight handler and waits for the workers to exit.
+// The cancelled attempts are not counted; their events are released back
+// to the queue. It returns the error that stopped the pool, if a
+// worker failed before Stop was called.
+func (p *Pool) Stop() error {
+	if !p.started.Load() {
+		return nil
+	}
+	p.stopWorkers(context.Canceled)
+	<-p.done
+	return p.Err()
+}
+
+// Drain stops claiming new events and waits for the in-flight ones to
+// finish. If ctx is done first the remaining handlers are cancelled as by
+// Stop, and Drain returns ctx's error.
+func (p *Pool) Drain(ctx context.Context) error {
+	if !p.started.Load() {
+		return nil
+	}
+
+	p.mu.Lock()
+	first := !p.draining && !p.stopped
+	if !p.draining {
+		p.draining = true
+		close(p.drain)
+	}
+	p.mu.Unlock()
+	if first {
+		p.log.Info().Msg("Draining worker pool")
+		if p.hooks.OnDrain != nil {
+			p.hooks.OnDrain()
+		}
+	}
+
+	idle := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(idle)
+	}()
+
+	var cutShort error
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		cutShort = ctx.Err()
+	}
+
+	p.stopWorkers(context.Canceled)
+	<-p.done
+	if cutShort != nil {
+		return cutShort
+	}
+	return p.Err()
+}
+
+// Done is closed once the pool has stopped, for whatever reason.
+func (p *Pool) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err is the unrecoverable error that stopped the pool, or nil when it was
+// stopped, drained or is still running.
+func (p *Pool) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// stopWorkers cancels the workers' context. It is done under mu so that
+// resize never starts a worker after the pool has begun waiting for them.
+func (p *Pool) stopWorkers(cause error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	p.cancel(cause)
+}
+
+// finish runs once every worker has exited: it stops the heartbeat, marks
+// the pool's row stopped and reports why the pool stopped.
+func (p *Pool) finish() {
+	<-p.heartbeatDone
+	p.markStopped()
+
+	var err error
+	if cause := context.Cause(p.ctx); !errors.Is(cause, context.Canceled) {
+		err = cause
+	}
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+
+	if err != nil {
+		p.log.Error().Err(err).Msg("Worker pool stopped with error")
+	} else {
+		p.log.Info().Msg("Worker pool stopped")
+	}
+	if p.hooks.OnStop != nil {
+		p.hooks.OnStop(err)
+	}
+	close(p.done)
+}
+
+// Apply swaps in new worker settings and grows or shrinks the pool to match.
+// The claim strategy, FairBy and FairWeights are fixed when the pool is
+// built and are not changed.
+func (p *Pool) Apply(workerCfg config.WorkerConfig) {
+	previous := p.settings.Swap(&workerCfg)
+	if previous.Chaos.Enabled && !workerCfg.Chaos.Enabled {
+		p.log.Warn().Msg("Chaos mode disabled")
+	} else if workerCfg.Chaos.Enabled && !reflect.DeepEqual(previous.Chaos, workerCfg.Chaos) {
+		p.logChaos(workerCfg.Chaos)
+	}
+	p.resize(workerCfg.PoolSize)
+}
+
+// resize starts or stops workers until n are running. Stopped workers finish
+// the event they hold before exiting, so shrinking never strands an event.
+func (p *Pool) resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctx == nil || p.stopped || p.draining {
+		return
+	}
+
+	for len(p.workers) < n {
+		p.nextID++
+		w := &workerHandle{id: p.nextID, stop: make(chan struct{})}
+		p.workers = append(p.workers, w)
+
+		ctx := p.ctx
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			if err := p.runWorker(ctx, w); err != nil && !errors.Is(err, context.Canceled) {
+				p.stopWorkers(err)
+			}
+		}()
+	}
+
+	for len(p.workers) > n {
+		last := p.workers[len(p.workers)-1]
+		close(last.stop)
+		p.workers = p.workers[:len(p.workers)-1]
+	}
+}
+
+// CheckLoop is the readiness check for the worker loops: some worker must
+// be busy with an attempt or have come back round to claim within the
+// heartbeat timeout. Handlers may run longer than the heartbeat timeout, so
+// a pool whose workers are all busy is not stuck.
+func (p *Pool) CheckLoop(ctx context.Context) error {
+	last := p.lastLoop.Load()
+	if last == 0 {
+		return errors.New("worker pool not started")
+	}
+	if p.busy.Load() > 0 {
+		return nil
+	}
+	if age, timeout := time.Since(time.Unix(0, last)), p.settings.Load().HeartbeatTimeout; age > timeout {
+		return fmt.Errorf("no worker loop for %s (timeout %s)", age.Round(time.Second), timeout)
+	}
+	return nil
+}
+
+func (p *Pool) runWorker(ctx context.Context, w *workerHandle) error {
+	p.log.Debug().Int("worker", w.id).Msg("Worker started")
+	defer p.log.Debug().Int("worker", w.id).Msg("Worker stopped")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.stop:
+			return nil
+		case <-p.drain:
+			return nil
+		default:
+		}
+
+		p.lastLoop.Store(time.Now().UnixNano())
+		settings := p.settings.Load()
+
+		event, err := p.claim(ctx, settings)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-w.stop:
+					return nil
+				case <-p.drain:
+					return nil
+				case <-time.After(settings.PollInterval):
+				}
+				continue
+			}
+			return err
+		}
+
+		eventType := typeKey(event)
+		if !p.acquire(eventType, settings) {
+			// Another worker took the last slot for this type between our
+			// saturation check and the claim; hand the event back.
+			if _, err := p.store.ReleaseWebhook(ctx, sqlc.ReleaseWebhookParams{ID: event.ID, ClaimedBy: p.id}); err != nil {
+				return fmt.Errorf("release webhook: %w", err)
+			}
+			continue
+		}
+
+		p.log.Debug().
+			Int("worker", w.id).
+			Str("event_id", event.EventID).
+			Str("tenant", event.TenantID).
+			Msg("Claimed webhook")
+		if p.hooks.OnClaim != nil {
+			p.hooks.OnClaim(ctx, event)
+		}
+
+		p.busy.Add(1)
+		err = p.attempt(ctx, p.workerID(w), event)
+		p.busy.Add(-1)
+		p.lastLoop.Store(time.Now().UnixNano())
+
+		if returned := abandoned(err); returned != nil {
+			// An abandoned handler counts against its type's limit until it
+			// actually returns.
+			go func() {
+				<-returned
+				p.releaseSlot(eventType)
+			}()
+		} else {
+			p.releaseSlot(eventType)
+		}
+	}
+}
+
+// claim takes the next event, from the partition the scheduler picks when
+// there is one. It returns pgx.ErrNoRows when there is nothing to claim.
+func (p *Pool) claim(ctx context.Context, settings *config.WorkerConfig) (sqlc.WebhookEvent, error) {
+	params := sqlc.ClaimNextWebhookParams{
+		ClaimedBy:     p.id,
+		ExcludedTypes: p.saturatedTypes(settings),
+	}
+
+	if p.scheduler != nil {
+		backlog, err := p.claimablePartitions(ctx, settings, params.ExcludedTypes)
+		if err != nil {
+			return sqlc.WebhookEvent{}, err
+		}
+		if len(backlog) == 0 {
+			return sqlc.WebhookEvent{}, pgx.ErrNoRows
+		}
+
+		params.PartitionBy = p.fairBy
+		params.Partition = p.scheduler.Pick(backlog)
+		event, err := p.store.ClaimNextWebhook(ctx, params)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return event, err
+		}
+
+		// The partition was drained since the backlog was listed. Claim the
+		// oldest event anywhere rather than sleep with work queued.
+		p.backlog.reset()
+		params.PartitionBy, params.Partition = "", ""
+	}
+
+	return p.store.ClaimNextWebhook(ctx, params)
+}
+
+// backlogCache holds the last ListClaimablePartitions result and the
+// excluded types it was listed with.
+type backlogCache struct {
+	mu         sync.Mutex
+	partitions []scheduler.Partition
+	excluded   []string
+	listedAt   time.Time
+}
+
+func (c *backlogCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.partitions = nil
+}
+
+// claimablePartitions lists the partitions with claimable work, reusing the
+// previous listing for up to a poll interval while the excluded types are
+// unchanged. An empty backlog is not cached, so new work is seen on the
+// next poll.
+func (p *Pool) claimablePartitions(ctx context.Context, settings *config.WorkerConfig, excluded []string) ([]scheduler.Partition, error) {
+	c := &p.backlog
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.partitions != nil && slices.Equal(c.excluded, excluded) && time.Since(c.listedAt) < settings.PollInterval {
+		return c.partitions, nil
+	}
+
+	rows, err := p.store.ListClaimablePartitions(ctx, sqlc.ListClaimablePartitionsParams{
+		PartitionBy:   p.fairBy,
+		ExcludedTypes: excluded,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list claimable partitions: %w", err)
+	}
+
+	partitions := make([]scheduler.Partition, len(rows))
+	for i, row := range rows {
+		partitions[i] = scheduler.Partition{Key: row.Partition, Depth: row.Depth}
+	}
+	if len(partitions) == 0 {
+		c.partitions = nil
+		return partitions, nil
+	}
+	c.partitions, c.excluded, c.listedAt = partitions, excluded, time.Now()
+	return partitions, nil
+}
+
+// saturatedTypes lists the event types already at their per-type limit, so
+// the claim query skips them. The list is sorted so the same set compares
+// equal to the one the backlog was listed with.
+func (p *Pool) saturatedTypes(settings *config.WorkerConfig) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saturated := []string{}
+	for eventType, limit := range settings.TypeLimits {
+		if p.inFlight[eventType] >= limit {
+			saturated = append(saturated, eventType)
+		}
+	}
+	slices.Sort(saturated)
+	return saturated
+}
+
+func (p *Pool) acquire(eventType string, settings *config.WorkerConfig) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if limit, ok := settings.TypeLimits[eventType]; ok && p.inFlight[eventType] >= limit {
+		return false
+	}
+	p.inFlight[eventType]++
+	return true
+}
+
+func (p *Pool) releaseSlot(eventType string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.inFlight[eventType]--
+	if p.inFlight[eventType] <= 0 {
+		delete(p.inFlight, eventType)
+	}
+}
+
+func typeKey(event sqlc.WebhookEvent) string {
+	if event.Type == nil {
+		return ""
+	}
+	return *event.Type
+}
@@ -0,0 +1,599 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"worker-pool/internal/config"
+	"worker-pool/internal/db"
+	"worker-pool/internal/db/dbtest"
+	"worker-pool/internal/db/mocks"
+	sqlc "worker-pool/internal/db/sqlc/generated"
+	"worker-pool/internal/scheduler"
+	"worker-pool/internal/worker"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions(store db.Store, handlers *worker.Registry) worker.Options {
+	settings := config.Default().Worker
+	settings.PoolSize = 2
+	settings.PollInterval = 5 * time.Millisecond
+	settings.HandlerTimeout = time.Second
+	settings.ClaimStrategy = config.ClaimFIFO
+	retry := config.Default().Retry
+	retry.MaxAttempts = 1
+	retry.InitialBackoff = time.Millisecond
+	retry.MaxBackoff = time.Millisecond
+	return worker.Options{Store: store, Handlers: handlers, Settings: settings, Retry: retry, Version: "test"}
+}
+
+func startPool(t *testing.T, opts worker.Options) *worker.Pool {
+	t.Helper()
+	p, err := worker.New(opts)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+	t.Cleanup(func() { _ = p.Stop() })
+	return p
+}
+
+func createEvent(t *testing.T, store *dbtest.Store, eventID, eventType string) sqlc.WebhookEvent {
+	t.Helper()
+	event, err := store.CreateWebhook(context.Background(), sqlc.CreateWebhookParams{
+		TenantID: "acme",
+		EventID:  eventID,
+		Type:     &eventType,
+		Payload:  []byte(`{}`),
+	})
+	require.NoError(t, err)
+	return event
+}
+
+// waitForStatus waits until every event in the store has the status.
+func waitForStatus(t *testing.T, store *dbtest.Store, status string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		for _, event := range store.Events() {
+			if event.Status != status {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func attempts(t *testing.T, store *dbtest.Store, event sqlc.WebhookEvent) []sqlc.WebhookAttempt {
+	t.Helper()
+	rows, err := store.ListWebhookAttempts(context.Background(), event.ID)
+	require.NoError(t, err)
+	return rows
+}
+
+func TestNew_RequiresStoreAndHandlers(t *testing.T) {
+	_, err := worker.New(testOptions(nil, worker.NewRegistry()))
+	assert.Error(t, err)
+
+	_, err = worker.New(testOptions(dbtest.NewStore(), nil))
+	assert.Error(t, err)
+
+	opts := testOptions(dbtest.NewStore(), worker.NewRegistry())
+	opts.Settings.ClaimStrategy = "lottery"
+	_, err = worker.New(opts)
+	assert.Error(t, err)
+}
+
+func TestPool_ProcessesEventsByType(t *testing.T) {
+	store := dbtest.NewStore()
+	var (
+		mu      sync.Mutex
+		handled = make(map[string]string)
+	)
+	record := func(name string) worker.EventHandler {
+		return func(ctx context.Context, event sqlc.WebhookEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[event.EventID] = name
+			return nil
+		}
+	}
+	handlers := worker.NewRegistry()
+	handlers.Handle("payment.completed", record("completed"))
+	handlers.HandleDefault(record("default"))
+
+	completed := createEvent(t, store, "evt_1", "payment.completed")
+	createEvent(t, store, "evt_2", "payment.refunded")
+
+	p := startPool(t, testOptions(store, handlers))
+	waitForStatus(t, store, db.DoneStatus)
+	require.NoError(t, p.Stop())
+
+	mu.Lock()
+	assert.Equal(t, map[string]string{"evt_1": "completed", "evt_2": "default"}, handled)
+	mu.Unlock()
+
+	history := attempts(t, store, completed)
+	require.Len(t, history, 1)
+	assert.Equal(t, db.AttemptSucceeded, history[0].Outcome)
+	assert.True(t, strings.HasPrefix(history[0].WorkerID, p.ID().String()), history[0].WorkerID)
+
+	workers, err := store.ListWorkers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, workers, 1)
+	assert.Equal(t, p.ID(), workers[0].ID)
+	assert.True(t, workers[0].StoppedAt.Valid)
+}
+
+func TestPool_RetriesThenFails(t *testing.T) {
+	store := dbtest.NewStore()
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		return errors.New("upstream unavailable")
+	})
+	event := createEvent(t, store, "evt_1", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Retry.MaxAttempts = 2
+	startPool(t, opts)
+	waitForStatus(t, store, db.FailedStatus)
+
+	failed := store.Events()[0]
+	require.NotNil(t, failed.LastError)
+	assert.Equal(t, "upstream unavailable", *failed.LastError)
+	history := attempts(t, store, event)
+	require.Len(t, history, 2)
+	assert.Equal(t, db.AttemptRetrying, history[0].Outcome)
+	assert.Equal(t, db.AttemptFailed, history[1].Outcome)
+}
+
+func TestPool_FailureReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler worker.EventHandler
+		reason  string
+	}{
+		{
+			name:    "error",
+			handler: func(ctx context.Context, event sqlc.WebhookEvent) error { return errors.New("boom") },
+			reason:  db.AttemptReasonError,
+		},
+		{
+			name:    "panic",
+			handler: func(ctx context.Context, event sqlc.WebhookEvent) error { panic("boom") },
+			reason:  db.AttemptReasonPanic,
+		},
+		{
+			name: "timeout",
+			handler: func(ctx context.Context, event sqlc.WebhookEvent) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			reason: db.AttemptReasonTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := dbtest.NewStore()
+			handlers := worker.NewRegistry()
+			handlers.HandleDefault(tt.handler)
+			event := createEvent(t, store, "evt_1", "payment.completed")
+
+			opts := testOptions(store, handlers)
+			opts.Settings.HandlerTimeout = 20 * time.Millisecond
+			startPool(t, opts)
+			waitForStatus(t, store, db.FailedStatus)
+
+			history := attempts(t, store, event)
+			require.Len(t, history, 1)
+			require.NotNil(t, history[0].Reason)
+			assert.Equal(t, tt.reason, *history[0].Reason)
+		})
+	}
+}
+
+func TestPool_RecoversHandlerPanic(t *testing.T) {
+	store := dbtest.NewStore()
+	handlers := worker.NewRegistry()
+	handlers.Handle("payment.failed", func(ctx context.Context, event sqlc.WebhookEvent) error {
+		panic("nil pointer in payment.failed handler")
+	})
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error { return nil })
+	panicking := createEvent(t, store, "evt_1", "payment.failed")
+	next := createEvent(t, store, "evt_2", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	startPool(t, opts)
+
+	// The one worker survived the panic and went on to claim the next event.
+	require.Eventually(t, func() bool {
+		events := store.Events()
+		return events[0].Status == db.FailedStatus && events[1].Status == db.DoneStatus
+	}, 5*time.Second, 5*time.Millisecond)
+
+	history := attempts(t, store, panicking)
+	require.Len(t, history, 1)
+	assert.Equal(t, db.AttemptFailed, history[0].Outcome)
+	require.NotNil(t, history[0].Reason)
+	assert.Equal(t, db.AttemptReasonPanic, *history[0].Reason)
+	require.NotNil(t, history[0].Error)
+	assert.Contains(t, *history[0].Error, "panic: nil pointer in payment.failed handler")
+	assert.Contains(t, *history[0].Error, "runtime/debug.Stack")
+	assert.Len(t, attempts(t, store, next), 1)
+}
+
+func TestPool_AbandonedHandlerKeepsEventUntilLeaseExpires(t *testing.T) {
+	store := dbtest.NewStore()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		if calls.Add(1) == 1 {
+			<-release // ignores its deadline
+		}
+		return nil
+	})
+	event := createEvent(t, store, "evt_1", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Retry.MaxAttempts = 3
+	opts.Settings.HandlerTimeout = 10 * time.Millisecond
+	opts.Settings.HeartbeatInterval = 20 * time.Millisecond
+	opts.Settings.HeartbeatTimeout = 200 * time.Millisecond
+	opts.Settings.TypeLimits = map[string]int{"payment.completed": 1}
+	startPool(t, opts)
+
+	require.Eventually(t, func() bool { return len(attempts(t, store, event)) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, db.ProcessingStatus, store.Events()[0].Status, "abandoned event must not be rescheduled while its lease holds")
+
+	// Once the lease runs out the event goes back to the queue, but the
+	// stuck handler still holds the type's only slot.
+	waitForStatus(t, store, db.ReceivedStatus)
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 1, calls.Load())
+
+	close(release)
+	waitForStatus(t, store, db.DoneStatus)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+// listCounter counts the partition listings of fair claims.
+type listCounter struct {
+	*dbtest.Store
+	lists atomic.Int32
+}
+
+func (s *listCounter) ListClaimablePartitions(ctx context.Context, arg sqlc.ListClaimablePartitionsParams) ([]sqlc.ListClaimablePartitionsRow, error) {
+	s.lists.Add(1)
+	return s.Store.ListClaimablePartitions(ctx, arg)
+}
+
+// pickFunc adapts a function to scheduler.Scheduler.
+type pickFunc func(backlog []scheduler.Partition) string
+
+func (f pickFunc) Pick(backlog []scheduler.Partition) string { return f(backlog) }
+
+func TestPool_FairClaimsReuseThePartitionListing(t *testing.T) {
+	store := &listCounter{Store: dbtest.NewStore()}
+	eventType := "payment.completed"
+	for i := range 9 {
+		tenant := []string{"acme", "globex", "initech"}[i%3]
+		store.PutEvent(sqlc.WebhookEvent{TenantID: tenant, EventID: fmt.Sprintf("evt_%d", i), Type: &eventType})
+	}
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error { return nil })
+
+	opts := testOptions(store.Store, handlers)
+	opts.Store = store
+	opts.Settings.PoolSize = 1
+	opts.Settings.PollInterval = time.Hour
+	opts.Settings.ClaimStrategy = config.ClaimRoundRobin
+	startPool(t, opts)
+
+	waitForStatus(t, store.Store, db.DoneStatus)
+	assert.EqualValues(t, 1, store.lists.Load(), "claims within a poll interval share one listing")
+}
+
+func TestPool_FairClaimsReuseTheListingWithSeveralSaturatedTypes(t *testing.T) {
+	store := &listCounter{Store: dbtest.NewStore()}
+	blocked := []string{"payment.failed", "payment.pending", "payment.refunded"}
+	for _, eventType := range blocked {
+		createEvent(t, store.Store, "evt_"+eventType, eventType)
+	}
+	for i := range 20 {
+		createEvent(t, store.Store, fmt.Sprintf("evt_%d", i), "payment.completed")
+	}
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		if *event.Type != "payment.completed" {
+			<-ctx.Done() // holds its type's only slot
+		}
+		return nil
+	})
+
+	opts := testOptions(store.Store, handlers)
+	opts.Store = store
+	opts.Settings.PoolSize = len(blocked) + 1
+	opts.Settings.PollInterval = time.Hour
+	opts.Settings.ClaimStrategy = config.ClaimRoundRobin
+	opts.Settings.TypeLimits = map[string]int{}
+	for _, eventType := range blocked {
+		opts.Settings.TypeLimits[eventType] = 1
+	}
+	startPool(t, opts)
+
+	require.Eventually(t, func() bool {
+		done := 0
+		for _, event := range store.Events() {
+			if event.Status == db.DoneStatus {
+				done++
+			}
+		}
+		return done == 20
+	}, 5*time.Second, 5*time.Millisecond)
+	// One listing per new set of saturated types as the blocked types fill
+	// up, and one by the free worker finding nothing left; the claims in
+	// between all exclude the same set and share a listing.
+	assert.LessOrEqual(t, store.lists.Load(), int32(len(blocked)+2))
+}
+
+func TestPool_FairClaimFallsBackWhenPartitionIsEmpty(t *testing.T) {
+	store := dbtest.NewStore()
+	createEvent(t, store, "evt_1", "payment.completed")
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error { return nil })
+
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	opts.Settings.PollInterval = time.Hour
+	// A listing gone stale: the picked partition has nothing to claim.
+	opts.Scheduler = pickFunc(func([]scheduler.Partition) string { return "drained" })
+	startPool(t, opts)
+
+	waitForStatus(t, store, db.DoneStatus)
+}
+
+func TestPool_LostLeaseCancelsHandlerAndDiscardsAttempt(t *testing.T) {
+	store := dbtest.NewStore()
+	started := make(chan struct{})
+	handlerErr := make(chan error, 1)
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		close(started)
+		<-ctx.Done()
+		handlerErr <- ctx.Err()
+		return ctx.Err()
+	})
+	event := createEvent(t, store, "evt_1", "payment.completed")
+
+	var settled atomic.Int32
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	opts.Settings.PollInterval = time.Hour
+	opts.Settings.HandlerTimeout = time.Minute
+	opts.Settings.HeartbeatInterval = 10 * time.Millisecond
+	opts.Settings.HeartbeatTimeout = time.Hour
+	opts.Retry.MaxAttempts = 3
+	opts.Hooks.OnAttempt = func(ctx context.Context, attempt worker.Attempt) { settled.Add(1) }
+	startPool(t, opts)
+
+	<-started
+	// Another pool reclaims the event as if the lease had run out. Pausing
+	// keeps this pool from claiming it again.
+	_, err := store.SetQueuePaused(context.Background(), sqlc.SetQueuePausedParams{Scope: "*", Paused: true})
+	require.NoError(t, err)
+	reclaimed, err := store.ReclaimExpiredWebhooks(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, reclaimed, 1)
+
+	select {
+	case err := <-handlerErr:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not cancelled when its lease was lost")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	current := store.Events()[0]
+	assert.Equal(t, db.ReceivedStatus, current.Status, "the stale attempt must not settle the reclaimed event")
+	assert.Equal(t, reclaimed[0].NextAttemptAt, current.NextAttemptAt)
+	assert.Empty(t, attempts(t, store, event))
+	assert.Zero(t, settled.Load())
+}
+
+func TestPool_NoHandler(t *testing.T) {
+	store := dbtest.NewStore()
+	createEvent(t, store, "evt_1", "payment.completed")
+
+	attempted := make(chan worker.Attempt, 1)
+	opts := testOptions(store, worker.NewRegistry())
+	opts.Hooks.OnAttempt = func(ctx context.Context, attempt worker.Attempt) {
+		attempted <- attempt
+	}
+	startPool(t, opts)
+
+	select {
+	case attempt := <-attempted:
+		assert.ErrorIs(t, attempt.Err, worker.ErrNoHandler)
+		assert.Equal(t, db.AttemptFailed, attempt.Outcome)
+		assert.Equal(t, "evt_1", attempt.Event.EventID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no attempt")
+	}
+}
+
+func TestPool_DrainFinishesInFlightEvents(t *testing.T) {
+	store := dbtest.NewStore()
+	claimed := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		<-release
+		return nil
+	})
+	createEvent(t, store, "evt_1", "payment.completed")
+
+	var hooks []string
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	opts.Hooks = worker.Hooks{
+		OnStart: func() { hooks = append(hooks, "start") },
+		OnClaim: func(ctx context.Context, event sqlc.WebhookEvent) { claimed <- struct{}{} },
+		OnDrain: func() { hooks = append(hooks, "drain") },
+		OnStop:  func(err error) { hooks = append(hooks, fmt.Sprintf("stop %v", err)) },
+	}
+	p := startPool(t, opts)
+	<-claimed
+
+	drained := make(chan error, 1)
+	go func() { drained <- p.Drain(context.Background()) }()
+	// Not claimed until after the drain began, so it must be left alone.
+	time.Sleep(20 * time.Millisecond)
+	createEvent(t, store, "evt_2", "payment.completed")
+
+	select {
+	case err := <-drained:
+		t.Fatalf("drain returned with an event in flight: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	require.NoError(t, <-drained)
+	assert.Equal(t, []string{"start", "drain", "stop <nil>"}, hooks)
+	events := store.Events()
+	assert.Equal(t, db.DoneStatus, events[0].Status)
+	assert.Equal(t, db.ReceivedStatus, events[1].Status)
+}
+
+func TestPool_DrainDeadlineCancelsHandlers(t *testing.T) {
+	store := dbtest.NewStore()
+	claimed := make(chan struct{}, 1)
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	createEvent(t, store, "evt_1", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Retry.MaxAttempts = 2
+	opts.Hooks.OnClaim = func(ctx context.Context, event sqlc.WebhookEvent) { claimed <- struct{}{} }
+	p := startPool(t, opts)
+	<-claimed
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Drain(ctx), context.DeadlineExceeded)
+
+	// The cancelled attempt is not counted: the event is handed back as it
+	// was before the claim.
+	event := store.Events()[0]
+	assert.Equal(t, db.ReceivedStatus, event.Status)
+	assert.Zero(t, event.Attempts)
+	assert.False(t, event.ClaimedBy.Valid)
+	assert.Empty(t, attempts(t, store, event))
+}
+
+func TestPool_StopCancelsInFlightHandlers(t *testing.T) {
+	store := dbtest.NewStore()
+	claimed := make(chan struct{}, 1)
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	createEvent(t, store, "evt_1", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Hooks.OnClaim = func(ctx context.Context, event sqlc.WebhookEvent) { claimed <- struct{}{} }
+	p := startPool(t, opts)
+	<-claimed
+
+	require.NoError(t, p.Stop())
+	<-p.Done()
+	assert.NoError(t, p.Err())
+
+	// Even on its last attempt, an event whose handler was cancelled by
+	// the shutdown is released rather than marked failed.
+	event := store.Events()[0]
+	assert.Equal(t, db.ReceivedStatus, event.Status)
+	assert.Zero(t, event.Attempts)
+	assert.Nil(t, event.LastError)
+	assert.Empty(t, attempts(t, store, event))
+}
+
+func TestPool_StoreErrorStopsPool(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().RegisterWorker(mock.Anything, mock.Anything).Return(sqlc.Worker{}, nil)
+	store.EXPECT().ClaimNextWebhook(mock.Anything, mock.Anything).Return(sqlc.WebhookEvent{}, errors.New("connection refused"))
+	store.EXPECT().StopWorker(mock.Anything, mock.Anything).Return(sqlc.Worker{}, nil)
+
+	stopped := make(chan error, 1)
+	opts := testOptions(store, worker.NewRegistry())
+	opts.Hooks.OnStop = func(err error) { stopped <- err }
+	p, err := worker.New(opts)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(context.Background()))
+
+	select {
+	case <-p.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool did not stop")
+	}
+	assert.EqualError(t, p.Err(), "connection refused")
+	assert.EqualError(t, <-stopped, "connection refused")
+	assert.EqualError(t, p.Stop(), "connection refused")
+}
+
+func TestPool_StartOnce(t *testing.T) {
+	store := dbtest.NewStore()
+	p := startPool(t, testOptions(store, worker.NewRegistry()))
+
+	assert.Error(t, p.Start(context.Background()))
+	require.NoError(t, p.CheckLoop(context.Background()))
+}
+
+func TestPool_CheckLoopPassesWhileWorkersAreBusy(t *testing.T) {
+	store := dbtest.NewStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handlers := worker.NewRegistry()
+	handlers.HandleDefault(func(ctx context.Context, event sqlc.WebhookEvent) error {
+		close(started)
+		<-release
+		return nil
+	})
+	createEvent(t, store, "evt_1", "payment.completed")
+
+	opts := testOptions(store, handlers)
+	opts.Settings.PoolSize = 1
+	opts.Settings.HeartbeatTimeout = 20 * time.Millisecond
+	p := startPool(t, opts)
+
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, p.CheckLoop(context.Background()), "a worker busy for longer than the heartbeat timeout is alive")
+
+	close(release)
+	waitForStatus(t, store, db.DoneStatus)
+	require.NoError(t, p.CheckLoop(context.Background()))
+}
+
+func TestPool_StartFailsWhenRegistrationFails(t *testing.T) {
+	store := mocks.NewStore(t)
+	store.EXPECT().RegisterWorker(mock.Anything, mock.Anything).Return(sqlc.Worker{}, pgx.ErrTxClosed)
+
+	p, err := worker.New(testOptions(store, worker.NewRegistry()))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, p.Start(context.Background()), pgx.ErrTxClosed)
+	assert.Error(t, p.CheckLoop(context.Background()))
+}